@@ -10,7 +10,9 @@ import (
 	"github.com/go-ldap/ldap/v3"
 	"github.com/google/martian/log"
 	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
 	"github.com/wangle201210/fyne-ldap-admin/config"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,15 +20,13 @@ import (
 type LdapAdmin struct {
 	fyne.App
 	sync.Mutex
-	windows       fyne.Window
-	resultWindow  fyne.Window
-	resultContent fyne.CanvasObject
-	statusLabel   *widget.Label
-	currentList   *widget.List
-	labels        [][]*canvas.Text
-	wg            *sync.WaitGroup
-	ldapPool      *dao.LDAPPool
-	detailContent *widget.TextGrid
+	windows      fyne.Window
+	resultWindow fyne.Window
+	resultTabs   *container.DocTabs
+	tabs         []*resultTab
+	labels       [][]*canvas.Text
+	wg           *sync.WaitGroup
+	ldapPool     *dao.LDAPPool
 	search
 }
 
@@ -35,10 +35,34 @@ type search struct {
 	ldapConn            *config.LdapConf
 	result              *fyne.Container
 	searchReq           *dao.SearchReq
-	pageControl         *ldap.ControlPaging
 	conn                *ldap.Conn
-	data                []*ldap.Entry // 搜索到的结果
-	selectData          *ldap.Entry   // 需要被显示的data
+	searchButton        *widget.Button
+	filterHighlight     *widget.RichText // 按括号层级着色的filter
+	filterStatus        *widget.Label
+	schema              *dao.Schema
+	schemaWindow        fyne.Window
+	quickSearchFilter   *widget.Label // 快速搜索生成的filter
+	searchStore         *config.SearchStore
+	sidebarTabs         *container.AppTabs
+	savedList           *widget.List
+	historyList         *widget.List
+	selectedSaved       int // 选中的收藏搜索, -1 表示未选中
+	baseDNEntry         *widget.SelectEntry
+	filterEntry         *ext.Entry
+	rootDSE             *dao.RootDSE
+	serverInfo          *fyne.Container
+	serverInfoItem      *widget.AccordionItem
+	dirTree             *widget.Tree
+	treeChildren        map[string][]string    // 已加载的子节点DN
+	treeLeaves          map[string]bool        // 没有子节点的DN
+	treeEntries         map[string]*ldap.Entry // 已加载的子节点, 用于显示图标和标记
+	treeSelected        string                 // 目录树中选中的DN
+	profiles            *config.ProfileStore
+	profileSelect       *widget.Select
+	profilePools        map[string]profilePool // 多服务器搜索使用的连接池, 键为配置名称
+	federatedButton     *widget.Button
+	saveColumnsTimer    *time.Timer
+	detailClosed        map[string]bool // 详情中折叠的分组
 }
 
 func NewApp(app fyne.App) *LdapAdmin {
@@ -49,56 +73,87 @@ func NewApp(app fyne.App) *LdapAdmin {
 	res.App.Settings().BuildType()
 	res.initConfig()
 
-	// Initialize LDAP connection pool
-	server, _ := res.ldapConn.Addr.Get()
-	port, _ := res.ldapConn.Port.Get()
-	username, _ := res.ldapConn.Username.Get()
-	password, _ := res.ldapConn.Password.Get()
-	
-	pool, err := dao.NewLDAPPool(&dao.LDAPConfig{
-		Server:   server,
-		Port:     port,
-		Username: username,
-		Password: password,
-		PoolSize: 5,
-		Timeout:  30 * time.Second,
-	})
-	
-	if err != nil {
-		log.Errorf("Failed to create LDAP pool: %v", err)
-	} else {
-		res.ldapPool = pool
-	}
+	res.connect()
 
 	app.Lifecycle().SetOnStopped(func() {
 		res.ldapConn.Save() // Save data on exit
-		if res.ldapPool != nil {
-			// Close all connections in pool
-			for i := 0; i < 5; i++ {
-				if conn, err := res.ldapPool.GetConnection(); err == nil {
-					conn.Close()
-				}
-			}
+		res.Lock()
+		pool := res.ldapPool
+		res.Unlock()
+		if pool != nil {
+			pool.Close()
 		}
+		res.closeProfilePools()
 	})
 
 	res.searchReq = dao.NewSearchReq()
+	res.searchStore = config.LoadSearchStore()
 	res.result = container.NewVBox()
 	
 	// Layout
-	content := container.NewVBox(
-		res.MainPanel(),
+	content := container.NewHSplit(
+		res.initSidebar(),
+		container.NewVBox(res.MainPanel()),
 	)
+	content.SetOffset(0.25)
 
 	res.windows.SetContent(content)
+	res.addShortcuts(res.windows)
 	return res
 }
 
 func (x *LdapAdmin) initConfig() {
 	x.ldapConn = config.InitLdapCon()
+	x.profiles = config.LoadProfileStore()
 	x.initConfigPanel()
 }
 
+// connect creates the LDAP connection pool for the current config, closing the
+// previous one, and reads the server info once connected.
+func (x *LdapAdmin) connect() {
+	x.Lock()
+	oldPool := x.ldapPool
+	oldConn := x.conn
+	x.ldapPool, x.conn = nil, nil
+	x.rootDSE, x.schema = nil, nil
+	x.Unlock()
+	if oldPool != nil {
+		oldPool.Close()
+	}
+	if oldConn != nil {
+		oldConn.Close()
+	}
+
+	// Initialize LDAP connection pool
+	server, _ := x.ldapConn.Addr.Get()
+	port, _ := x.ldapConn.Port.Get()
+	username, _ := x.ldapConn.Username.Get()
+	password, _ := x.ldapConn.Password.Get()
+
+	pool, err := dao.NewLDAPPool(&dao.LDAPConfig{
+		Server:   server,
+		Port:     port,
+		Username: username,
+		Password: password,
+		PoolSize: 5,
+		Timeout:  30 * time.Second,
+	})
+
+	if err != nil {
+		log.Errorf("Failed to create LDAP pool: %v", err)
+		return
+	}
+	x.Lock()
+	x.ldapPool = pool
+	x.Unlock()
+	go func() {
+		if conn, err := pool.GetConnection(); err == nil {
+			x.onConnected(conn)
+			pool.ReleaseConnection(conn)
+		}
+	}()
+}
+
 func (x *LdapAdmin) initConfigPanel() {
 	serverEntry := widget.NewEntryWithData(x.ldapConn.Addr)
 	serverEntry.SetPlaceHolder("LDAP Server Address")
@@ -112,17 +167,54 @@ func (x *LdapAdmin) initConfigPanel() {
 	passwordEntry.Bind(x.ldapConn.Password)
 	passwordEntry.SetPlaceHolder("Password")
 
+	nameEntry := widget.NewEntryWithData(x.ldapConn.Name)
+	nameEntry.SetPlaceHolder("配置名称")
+
 	limitEntry := widget.NewEntryWithData(binding.IntToString(x.ldapConn.Limit))
-	limitEntry.SetPlaceHolder("限制返回条数")
+	limitEntry.SetPlaceHolder("每页条数")
+
+	sizeLimitEntry := widget.NewEntryWithData(binding.IntToString(x.ldapConn.SizeLimit))
+	sizeLimitEntry.SetPlaceHolder("最大返回条数, 0 不限制")
+
+	timeLimitEntry := widget.NewEntryWithData(binding.IntToString(x.ldapConn.TimeLimit))
+	timeLimitEntry.SetPlaceHolder("搜索超时秒数, 0 不限制")
+
+	quickAttrsEntry := widget.NewEntryWithData(x.ldapConn.QuickSearchAttrs)
+	quickAttrsEntry.SetPlaceHolder("快速搜索匹配的属性, 逗号分隔")
+
+	templatesEntry := widget.NewEntryWithData(x.ldapConn.DisplayTemplates)
+	templatesEntry.MultiLine = true
+	templatesEntry.SetMinRowsVisible(4)
+	templatesEntry.SetPlaceHolder("结果显示模板, 每行一个, 如 posixGroup: {cn} ({gidNumber})")
+
+	iconsEntry := widget.NewEntryWithData(x.ldapConn.ClassIcons)
+	iconsEntry.MultiLine = true
+	iconsEntry.SetMinRowsVisible(4)
+	iconsEntry.SetPlaceHolder("图标, 每行一个, 如 posixGroup: group, 可用: " + strings.Join(iconNames(), ", "))
+
+	cloneRulesEntry := widget.NewEntryWithData(x.ldapConn.CloneRules)
+	cloneRulesEntry.MultiLine = true
+	cloneRulesEntry.SetMinRowsVisible(4)
+	cloneRulesEntry.SetPlaceHolder("复制条目的规则, 每行一个, 如 uidNumber: next, 可用: clear, next, keep 或 {属性} 模板")
 
 	configAccordionItem := &widget.AccordionItem{
 		Title: "配置",
 		Detail: container.NewVBox(
+			x.initProfileBar(),
+			nameEntry,
 			serverEntry,
 			portEntry,
 			usernameEntry,
 			passwordEntry,
 			limitEntry,
+			widget.NewForm(
+				widget.NewFormItem("Size limit", sizeLimitEntry),
+				widget.NewFormItem("Time limit (s)", timeLimitEntry),
+			),
+			quickAttrsEntry,
+			templatesEntry,
+			iconsEntry,
+			cloneRulesEntry,
 		),
 		Open: true,
 	}
@@ -131,41 +223,108 @@ func (x *LdapAdmin) initConfigPanel() {
 
 func (x *LdapAdmin) MainPanel() *fyne.Container {
 
-	baseDNEntry := widget.NewEntryWithData(x.searchReq.BaseDN)
-	baseDNEntry.SetPlaceHolder("Base DN")
+	x.baseDNEntry = widget.NewSelectEntry(nil)
+	x.baseDNEntry.Bind(x.searchReq.BaseDN)
+	x.baseDNEntry.SetPlaceHolder("Base DN")
+
+	x.filterEntry = ext.NewEntry()
+	x.filterEntry.Bind(x.searchReq.Filter)
+	x.filterEntry.SetPlaceHolder("Search Filter (e.g., (objectClass=*))")
 
-	filterEntry := widget.NewEntryWithData(x.searchReq.Filter)
-	filterEntry.SetPlaceHolder("Search Filter (e.g., (objectClass=*))")
+	scopeSelect := widget.NewSelect([]string{dao.ScopeBase, dao.ScopeOne, dao.ScopeSub}, func(scope string) {
+		x.searchReq.Scope.Set(scope)
+	})
+	x.searchReq.Scope.AddListener(binding.NewDataListener(func() {
+		scope, _ := x.searchReq.Scope.Get()
+		scopeSelect.SetSelected(scope)
+	}))
+
+	attributesEntry := ext.NewEntry()
+	attributesEntry.Bind(x.searchReq.Attributes)
+	attributesEntry.SetPlaceHolder("Attributes, comma separated (empty for defaults)")
+
+	sortEntry := ext.NewEntry()
+	sortEntry.Bind(x.searchReq.Sort)
+	sortEntry.SetPlaceHolder("Sort by attribute (prefix - for descending)")
+
+	quickSearchEntry := ext.NewEntry()
+	quickSearchEntry.SetPlaceHolder("Quick search, e.g. zhang wei")
+	quickSearchEntry.OnChanged = x.quickSearchChanged
+	quickSearchEntry.OnSubmitted = func(string) {
+		if !x.searchButton.Disabled() {
+			x.Search()
+		}
+	}
+	x.quickSearchFilter = widget.NewLabel("")
+	x.quickSearchFilter.Wrapping = fyne.TextWrapBreak
+	x.quickSearchFilter.Hide()
 
-	searchButton := widget.NewButton("Search", x.Search)
+	x.searchButton = widget.NewButton("Search", x.Search)
+	x.federatedButton = widget.NewButton("Search Profiles...", x.FederatedSearchShow)
+	x.initFilterValidation()
+	x.initServerInfoPanel()
 	accordion := widget.NewAccordion(
 		x.configAccordionItem,
+		x.serverInfoItem,
 	)
 	content := container.NewVBox(
 		accordion,
-		baseDNEntry,
-		filterEntry,
-		searchButton,
+		container.NewGridWithColumns(2,
+			container.NewBorder(nil, nil, nil, scopeSelect, x.baseDNEntry),
+			quickSearchEntry,
+		),
+		x.quickSearchFilter,
+		x.filterEntry,
+		x.filterHighlight,
+		x.filterStatus,
+		attributesEntry,
+		sortEntry,
+		container.NewGridWithColumns(3, x.searchButton, x.federatedButton, widget.NewButton("New Entry...", x.NewEntryShow)),
 		// x.result,
 	)
+	x.showServerInfo() // rootDSE may have been read before the panel existed
 	return content
 }
 
-func (x *LdapAdmin) GetConn() (conn *ldap.Conn) {
-	if x.ldapPool != nil {
-		conn, err := x.ldapPool.GetConnection()
+// quickSearchChanged turns the quick search text into a filter over the
+// profile's quick search attributes and shows it, so it can be learned from.
+func (x *LdapAdmin) quickSearchChanged(text string) {
+	attrs, _ := x.ldapConn.QuickSearchAttrs.Get()
+	filter := dao.QuickSearchFilter(text, splitAttributes(attrs))
+	if filter == "" {
+		x.quickSearchFilter.Hide()
+		return
+	}
+	x.searchReq.Filter.Set(filter)
+	x.quickSearchFilter.SetText("Filter: " + filter)
+	x.quickSearchFilter.Show()
+}
+
+// GetConn takes a connection from the pool of the current profile. release
+// gives it back to the pool it came from, even when connect replaced the
+// pool in the meantime.
+func (x *LdapAdmin) GetConn() (conn *ldap.Conn, release func()) {
+	x.Lock()
+	pool := x.ldapPool
+	x.Unlock()
+	if pool != nil {
+		conn, err := pool.GetConnection()
 		if err == nil {
-			return conn
+			return conn, func() { pool.ReleaseConnection(conn) }
 		}
 		log.Errorf("Failed to get connection from pool: %v", err)
 	}
-	
-	// Fallback to direct connection
-	if x.conn != nil {
-		return x.conn
+
+	// Fallback to direct connection, it is shared and never released
+	x.Lock()
+	defer x.Unlock()
+	if x.conn == nil {
+		x.conn, _ = x.GetLdapConn()
+	}
+	if x.conn == nil {
+		return nil, nil
 	}
-	x.conn, _ = x.GetLdapConn()
-	return x.conn
+	return x.conn, func() {}
 }
 
 func (x *LdapAdmin) GetLdapConn() (conn *ldap.Conn, err error) {
@@ -183,65 +342,22 @@ func (x *LdapAdmin) GetLdapConn() (conn *ldap.Conn, err error) {
 	return
 }
 
+// Search runs the search of the main panel in a new result tab
 func (x *LdapAdmin) Search() {
-	x.doSearch(true)
-}
-
-func (x *LdapAdmin) SearchNextPage() {
-	x.doSearch(false)
-}
-
-func (x *LdapAdmin) doSearch(isFirst bool) {
 	x.configAccordionItem.Open = false
 	x.windows.Content().Refresh()
-	x.result.RemoveAll()
 
-	ldapConn := x.GetConn()
-	if ldapConn == nil {
-		x.result.Add(widget.NewLabel("Failed to establish LDAP connection"))
-		return
-	}
-	
-	defer func() {
-		if x.ldapPool != nil {
-			x.ldapPool.ReleaseConnection(ldapConn)
-		}
-	}()
-
-	baseDN, _ := x.searchReq.BaseDN.Get()
-	filter, _ := x.searchReq.Filter.Get()
-	limit, _ := x.ldapConn.Limit.Get()
-	if limit == 0 || limit > 100 {
-		limit = 1000
-	}
-	
-	if isFirst {
-		x.search.pageControl = ldap.NewControlPaging(uint32(limit))
-	}
-	
-	if x.search.pageControl == nil {
-		x.result.Add(widget.NewLabel("No more pages available"))
-		return
-	}
-
-	// Define attributes to retrieve
-	attributes := []string{
-		"cn", "sn", "givenName",
-		"mail", "telephoneNumber",
-		"uid", "uidNumber", "gidNumber",
-		"o", "ou", "title",
-		"objectClass", "createTimestamp", "modifyTimestamp",
+	query := x.currentSearch()
+	if x.openTab(newResultTab(x, query, nil)) {
+		x.addHistory(query)
 	}
+}
 
-	entries, err := dao.Search(ldapConn, baseDN, filter, x.search.pageControl, attributes)
-	if err != nil {
-		log.Errorf("Search failed: %v", err)
-		x.result.Add(widget.NewLabel(fmt.Sprintf("Search failed: %v", err)))
-		return
+// SearchNextPage shows the next page of the current result tab
+func (x *LdapAdmin) SearchNextPage() {
+	if t := x.currentTab(); t != nil {
+		t.rerun(false)
 	}
-
-	x.data = entries
-	x.ResultShow()
 }
 
 func (x *LdapAdmin) Run() {
@@ -0,0 +1,432 @@
+package app
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// batchResult is the outcome of a batch action on one entry
+type batchResult struct {
+	dn  string
+	err error
+}
+
+// modify operations offered by the batch modification
+const (
+	modifyAdd     = "add"
+	modifyReplace = "replace"
+	modifyDelete  = "delete"
+)
+
+// keyModifiers returns the modifier keys held down right now
+func (x *LdapAdmin) keyModifiers() fyne.KeyModifier {
+	if d, ok := x.App.Driver().(desktop.Driver); ok {
+		return d.CurrentKeyModifiers()
+	}
+	return 0
+}
+
+// clickEntry updates the selection when a result is clicked: shift extends
+// it to a range, ctrl toggles the entry and a plain click selects only it.
+func (t *resultTab) clickEntry(id int) {
+	// 点击列表不会让输入框失去焦点, 这里取消焦点, 让 Del 等按键交给窗口的快捷键
+	t.x.resultWindow.Canvas().Unfocus()
+	mods := t.x.keyModifiers()
+	toggle := mods&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0
+
+	t.mu.Lock()
+	if id < 0 || id >= len(t.shown) {
+		t.mu.Unlock()
+		return
+	}
+	entry := t.shown[id]
+	switch {
+	case mods&fyne.KeyModifierShift != 0 && t.selectAnchor >= 0 && t.selectAnchor < len(t.shown):
+		if !toggle {
+			t.selection = make(map[*ldap.Entry]bool)
+		}
+		from, to := min(t.selectAnchor, id), max(t.selectAnchor, id)
+		for _, e := range t.shown[from : to+1] {
+			t.selection[e] = true
+		}
+	case toggle:
+		if t.selection == nil {
+			t.selection = make(map[*ldap.Entry]bool)
+		}
+		if t.selection[entry] {
+			delete(t.selection, entry)
+		} else {
+			t.selection[entry] = true
+		}
+		t.selectAnchor = id
+	default:
+		t.selection = map[*ldap.Entry]bool{entry: true}
+		t.selectAnchor = id
+	}
+	t.selectData = entry
+	t.mu.Unlock()
+	t.refreshDetailView()
+	t.refreshSelection()
+}
+
+// selectAll selects every shown result
+func (t *resultTab) selectAll() {
+	t.mu.Lock()
+	t.selection = make(map[*ldap.Entry]bool, len(t.shown))
+	for _, entry := range t.shown {
+		t.selection[entry] = true
+	}
+	t.selectAnchor = -1
+	t.mu.Unlock()
+	t.refreshSelection()
+}
+
+// clearSelection forgets the selection, keeping selected entries that are
+// still shown when keepShown is set.
+func (t *resultTab) clearSelection(keepShown bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.selectAnchor = -1
+	if !keepShown {
+		t.selection = nil
+		return
+	}
+	shown := make(map[*ldap.Entry]bool, len(t.selection))
+	for _, entry := range t.shown {
+		if t.selection[entry] {
+			shown[entry] = true
+		}
+	}
+	t.selection = shown
+}
+
+// selectedEntries returns the selected entries in the order they are shown
+func (t *resultTab) selectedEntries() []*ldap.Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var entries []*ldap.Entry
+	for _, entry := range t.shown {
+		if t.selection[entry] {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// refreshSelection redraws the selected rows and the batch button
+func (t *resultTab) refreshSelection() {
+	if t.currentList != nil {
+		t.currentList.Refresh()
+	}
+	if t.currentTable != nil {
+		t.currentTable.Refresh()
+	}
+	if t.batchButton == nil {
+		return
+	}
+	n := len(t.selectedEntries())
+	t.batchButton.SetText(fmt.Sprintf("%d selected", n))
+	if n == 0 {
+		t.batchButton.Disable()
+	} else {
+		t.batchButton.Enable()
+	}
+}
+
+// selectionBackground colors the background of a row by its selection state
+func (t *resultTab) selectionBackground(bg *canvas.Rectangle, entry *ldap.Entry) {
+	t.mu.Lock()
+	selected := t.selection[entry]
+	t.mu.Unlock()
+	if selected {
+		bg.FillColor = theme.Color(theme.ColorNameSelection)
+	} else {
+		bg.FillColor = color.Transparent
+	}
+	bg.Refresh()
+}
+
+// initBatchButton creates the toolbar menu with the actions on the selection
+func (t *resultTab) initBatchButton() fyne.CanvasObject {
+	t.batchButton = widget.NewButtonWithIcon("0 selected", theme.MenuDropDownIcon(), nil)
+	t.batchButton.OnTapped = func() {
+		menu := fyne.NewMenu("",
+			fyne.NewMenuItem("Export LDIF...", t.exportSelection),
+			fyne.NewMenuItem("Add to Group...", t.addSelectionToGroup),
+			fyne.NewMenuItem("Modify...", t.modifySelection),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Delete...", t.deleteSelection),
+		)
+		widget.ShowPopUpMenuAtRelativePosition(menu, t.x.resultWindow.Canvas(),
+			fyne.NewPos(0, t.batchButton.Size().Height), t.batchButton)
+	}
+	t.refreshSelection()
+	return t.batchButton
+}
+
+// runBatch runs an action on every entry, each on a connection to the server
+// the entry came from, and reports the outcome per entry.
+func (t *resultTab) runBatch(title string, entries []*ldap.Entry, action func(conn *ldap.Conn, entry *ldap.Entry) error) {
+	progress := widget.NewProgressBar()
+	progress.Max = float64(len(entries))
+	wait := dialog.NewCustomWithoutButtons(title, progress, t.x.resultWindow)
+	wait.Show()
+
+	results := make([]batchResult, 0, len(entries))
+	for i, entry := range entries {
+		res := batchResult{dn: entry.DN}
+		conn, release, err := t.connFor(entry)
+		if err == nil {
+			res.err = action(conn, entry)
+			release()
+		} else {
+			res.err = err
+		}
+		results = append(results, res)
+		progress.SetValue(float64(i + 1))
+	}
+	wait.Hide()
+	showBatchReport(title, results, t.x.resultWindow)
+}
+
+// showBatchReport lists which entries a batch action succeeded or failed on
+func showBatchReport(title string, results []batchResult, parent fyne.Window) {
+	failed := 0
+	for _, res := range results {
+		if res.err != nil {
+			failed++
+		}
+	}
+	summary := widget.NewLabel(fmt.Sprintf("%d succeeded, %d failed", len(results)-failed, failed))
+	if failed > 0 {
+		summary.Importance = widget.WarningImportance
+	}
+
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Template")
+			label.Wrapping = fyne.TextWrapWord
+			return label
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			label := item.(*widget.Label)
+			res := results[id]
+			if res.err != nil {
+				label.Importance = widget.DangerImportance
+				label.SetText(fmt.Sprintf("FAILED %s: %v", res.dn, res.err))
+			} else {
+				label.Importance = widget.SuccessImportance
+				label.SetText("OK " + res.dn)
+			}
+		},
+	)
+	d := dialog.NewCustom(title, "Close", container.NewBorder(summary, nil, nil, nil, list), parent)
+	d.Resize(fyne.NewSize(700, 400))
+	d.Show()
+}
+
+// exportSelection writes the selected entries to an LDIF file
+func (t *resultTab) exportSelection() {
+	entries := t.selectedEntries()
+	fileDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, t.x.resultWindow)
+			return
+		}
+		if writer == nil {
+			return // cancelled
+		}
+		defer writer.Close()
+		results := make([]batchResult, 0, len(entries))
+		for _, entry := range entries {
+			results = append(results, batchResult{dn: entry.DN, err: dao.WriteLDIF(writer, entry)})
+		}
+		showBatchReport("Export LDIF", results, t.x.resultWindow)
+	}, t.x.resultWindow)
+	fileDialog.SetFileName("entries.ldif")
+	fileDialog.Show()
+}
+
+// deleteSelection deletes the selected entries after asking
+func (t *resultTab) deleteSelection() {
+	t.deleteEntries(t.selectedEntries())
+}
+
+// deleteCurrent deletes the selected entries, or the entry shown in the
+// detail view when nothing is selected. A single entry can be deleted with
+// the entries below it.
+func (t *resultTab) deleteCurrent() {
+	entries := t.selectedEntries()
+	if entry := t.detailEntry(); len(entries) == 0 && entry != nil {
+		entries = []*ldap.Entry{entry}
+	}
+	switch len(entries) {
+	case 0:
+	case 1:
+		t.x.deleteEntryShow(entries[0].DN, t.sourceOf(entries[0]), t.x.resultWindow, t.removeDeleted)
+	default:
+		t.deleteEntries(entries)
+	}
+}
+
+// deleteEntries deletes entries of the tab after asking
+func (t *resultTab) deleteEntries(entries []*ldap.Entry) {
+	dialog.ShowConfirm("Delete Entries", fmt.Sprintf("Delete %d entries? This cannot be undone.", len(entries)), func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			deleted := make(map[*ldap.Entry]bool)
+			t.runBatch("Delete Entries", entries, func(conn *ldap.Conn, entry *ldap.Entry) error {
+				if err := dao.Delete(conn, entry.DN); err != nil {
+					return err
+				}
+				deleted[entry] = true
+				return nil
+			})
+			t.removeEntries(deleted)
+		}()
+	}, t.x.resultWindow)
+}
+
+// addSelectionToGroup adds the selected entries to a group
+func (t *resultTab) addSelectionToGroup() {
+	entries := t.selectedEntries()
+	askText("Add to Group", "Group DN", "", t.x.resultWindow, func(groupDN string) {
+		if _, err := ldap.ParseDN(groupDN); err != nil {
+			dialog.ShowError(fmt.Errorf("invalid group DN: %w", err), t.x.resultWindow)
+			return
+		}
+		go t.runBatch("Add to Group", entries, func(conn *ldap.Conn, entry *ldap.Entry) error {
+			return dao.AddMember(conn, groupDN, entry)
+		})
+	})
+}
+
+// modifySelection applies one modification to all selected entries
+func (t *resultTab) modifySelection() {
+	entries := t.selectedEntries()
+	operation := widget.NewSelect([]string{modifyAdd, modifyReplace, modifyDelete}, nil)
+	operation.SetSelected(modifyReplace)
+	var options []string
+	for _, entry := range entries {
+		for _, attr := range entry.Attributes {
+			if !containsFold(options, attr.Name) {
+				options = append(options, attr.Name)
+			}
+		}
+	}
+	sort.Strings(options)
+	attribute := widget.NewSelectEntry(options)
+	values := widget.NewMultiLineEntry()
+	values.SetPlaceHolder("One value per line, none deletes the attribute")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Operation", operation),
+		widget.NewFormItem("Attribute", attribute),
+		widget.NewFormItem("Values", values),
+	}
+	form := dialog.NewForm("Modify Entries", "Apply", "Cancel", items, func(ok bool) {
+		attr := strings.TrimSpace(attribute.Text)
+		if !ok || attr == "" {
+			return
+		}
+		var vals []string
+		for _, v := range strings.Split(values.Text, "\n") {
+			if v = strings.TrimRight(v, "\r"); v != "" {
+				vals = append(vals, v)
+			}
+		}
+		op := operation.Selected
+		go func() {
+			updated := make(map[*ldap.Entry]*ldap.Entry)
+			t.runBatch("Modify Entries", entries, func(conn *ldap.Conn, entry *ldap.Entry) error {
+				req := ldap.NewModifyRequest(entry.DN, nil)
+				switch op {
+				case modifyAdd:
+					req.Add(attr, vals)
+				case modifyDelete:
+					req.Delete(attr, vals)
+				default:
+					req.Replace(attr, vals)
+				}
+				if err := conn.Modify(req); err != nil {
+					return fmt.Errorf("modify %s: %w", entry.DN, err)
+				}
+				// 重新读取, 以便显示修改后的值
+				if fresh, err := dao.ReadEntry(conn, entry.DN, t.x.searchOptions(t.query).Attributes); err == nil {
+					updated[entry] = fresh
+				}
+				return nil
+			})
+			t.replaceEntries(updated)
+		}()
+	}, t.x.resultWindow)
+	form.Resize(fyne.NewSize(500, 350))
+	form.Show()
+}
+
+// removeEntries drops deleted entries from the results
+func (t *resultTab) removeEntries(removed map[*ldap.Entry]bool) {
+	if len(removed) == 0 {
+		return
+	}
+	t.mu.Lock()
+	data := make([]*ldap.Entry, 0, len(t.data))
+	for _, entry := range t.data {
+		if removed[entry] {
+			delete(t.sources, entry)
+			delete(t.selection, entry)
+			continue
+		}
+		data = append(data, entry)
+	}
+	t.data = data
+	if removed[t.selectData] {
+		t.selectData = nil
+	}
+	t.mu.Unlock()
+	t.applyResultFilter()
+}
+
+// replaceEntries puts entries read again after a change in place of the old ones
+func (t *resultTab) replaceEntries(updated map[*ldap.Entry]*ldap.Entry) {
+	if len(updated) == 0 {
+		return
+	}
+	t.mu.Lock()
+	for i, entry := range t.data {
+		if fresh, ok := updated[entry]; ok {
+			t.data[i] = fresh
+		}
+	}
+	// 详情中的条目可能不在结果列表里, 也一起替换
+	for entry, fresh := range updated {
+		if source, ok := t.sources[entry]; ok {
+			delete(t.sources, entry)
+			t.sources[fresh] = source
+		}
+		if t.selection[entry] {
+			delete(t.selection, entry)
+			t.selection[fresh] = true
+		}
+		if t.selectData == entry {
+			t.selectData = fresh
+		}
+	}
+	t.mu.Unlock()
+	t.applyResultFilter()
+}
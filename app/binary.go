@@ -0,0 +1,92 @@
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"image"
+	_ "image/gif" // 注册图片解码器
+	_ "image/jpeg"
+	_ "image/png"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// binaryShownLength cuts long binary values in the detail view, copying
+// still gives the whole value
+const binaryShownLength = 1000
+
+// photoSize is the largest size a photo attribute is shown at
+var photoSize = fyne.NewSize(160, 160)
+
+// binaryText renders a binary value as text, certificates take several lines
+func binaryText(kind dao.BinaryKind, b []byte) string {
+	var text string
+	var err error
+	switch kind {
+	case dao.BinaryGUID:
+		text, err = dao.FormatGUID(b)
+	case dao.BinarySID:
+		text, err = dao.FormatSID(b)
+	case dao.BinaryCertificate:
+		text, err = dao.DescribeCertificate(b)
+	case dao.BinaryImage:
+		var config image.Config
+		var format string
+		if config, format, err = image.DecodeConfig(bytes.NewReader(b)); err == nil {
+			text = fmt.Sprintf("%s image, %dx%d, %d bytes", format, config.Width, config.Height, len(b))
+		}
+	default:
+		return dao.FormatBinary(b)
+	}
+	if err != nil {
+		return dao.FormatBinary(b)
+	}
+	return text
+}
+
+// binaryCopy is what the copy button puts on the clipboard: certificates as
+// PEM, images and unreadable values as base64.
+func binaryCopy(kind dao.BinaryKind, b []byte) string {
+	switch kind {
+	case dao.BinaryCertificate:
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b}))
+	case dao.BinaryGUID, dao.BinarySID:
+		return binaryText(kind, b)
+	}
+	if dao.IsPrintable(b) {
+		return string(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// binaryValue shows one binary value in the detail view, photos as images
+func (t *resultTab) binaryValue(attr string, kind dao.BinaryKind, b []byte) fyne.CanvasObject {
+	text := binaryText(kind, b)
+	if len(text) > binaryShownLength {
+		n := binaryShownLength
+		for n > 0 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		text = text[:n] + "…"
+	}
+	label := widget.NewLabel(text)
+	label.Wrapping = fyne.TextWrapBreak
+
+	var content fyne.CanvasObject = label
+	if kind == dao.BinaryImage {
+		if _, _, err := image.DecodeConfig(bytes.NewReader(b)); err == nil {
+			img := canvas.NewImageFromReader(bytes.NewReader(b), attr)
+			img.FillMode = canvas.ImageFillContain
+			img.SetMinSize(photoSize)
+			content = container.NewVBox(container.NewHBox(img), label)
+		}
+	}
+	return t.valueRow(content, binaryCopy(kind, b))
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// cloneRules returns the clone rules of a profile, the current profile when
+// name is empty. Clones follow the rules of the profile they are created in.
+func (x *LdapAdmin) cloneRules(name string) []config.CloneRule {
+	text := x.profileSetting(name, x.ldapConn.CloneRules, func(data *config.LdapConfData) string {
+		return data.CloneRules
+	})
+	if text == "" {
+		text = config.DefaultCloneRules
+	}
+	return config.ParseCloneRules(text)
+}
+
+// cloneEntryShow reads an entry again with all its attributes and opens the
+// new-entry wizard with a copy of it, below the same parent and in the same
+// profile. The clone rules decide which values are copied.
+func (x *LdapAdmin) cloneEntryShow(dn, source string, parent fyne.Window) {
+	if current, _ := x.ldapConn.Name.Get(); source == current {
+		source = ""
+	}
+	conn, release, err := x.connFor(source)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	entry, err := dao.ReadEntry(conn, dn, nil)
+	release()
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	clone, err := x.cloneTemplate(entry, source)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	x.newEntryWizard(clone, dao.ParentDN(dn), parent)
+}
+
+// cloneTemplate copies the classes, naming attribute and values of an entry.
+// Attributes set by the server are left out. profile is where the clone is
+// created, empty for the current profile.
+func (x *LdapAdmin) cloneTemplate(entry *ldap.Entry, profile string) (*entryClone, error) {
+	x.Lock()
+	schema := x.schema
+	x.Unlock()
+
+	rdn, _ := dao.SplitDN(entry.DN)
+	rdnAttr, _, _ := strings.Cut(rdn, "=")
+	clone := &entryClone{
+		entryTemplate: entryTemplate{
+			name:    "Copy of " + rdn,
+			classes: entry.GetAttributeValues("objectClass"),
+			rdn:     strings.TrimSpace(rdnAttr),
+		},
+		values:  make(map[string][]string),
+		profile: profile,
+	}
+	rules := x.cloneRules(profile)
+	for _, attr := range entry.Attributes {
+		if strings.EqualFold(attr.Name, "objectClass") {
+			continue
+		}
+		if schema != nil {
+			if at := schema.AttributeType(attr.Name); at != nil && at.NoUserModification {
+				continue
+			}
+		}
+		clone.attrs = append(clone.attrs, attr.Name)
+
+		rule := config.CloneRule{Attr: attr.Name, Rule: config.CloneKeep}
+		for _, r := range rules {
+			if strings.EqualFold(r.Attr, attr.Name) {
+				rule = r
+			}
+		}
+		switch strings.ToLower(rule.Rule) {
+		case config.CloneKeep:
+			clone.values[strings.ToLower(attr.Name)] = attr.Values
+		case config.CloneClear:
+		case config.CloneNext:
+			n, err := x.nextNumber(profile, entry.DN, attr.Name)
+			if err != nil {
+				return nil, err
+			}
+			clone.values[strings.ToLower(attr.Name)] = []string{strconv.Itoa(n)}
+		default:
+			clone.fill = append(clone.fill, rule)
+		}
+	}
+	return clone, nil
+}
+
+// nextNumber finds the next free number of an attribute in the naming
+// context that holds dn, on the server of a profile, empty for the current one
+func (x *LdapAdmin) nextNumber(profile, dn, attr string) (int, error) {
+	conn, release, err := x.connFor(profile)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	var namingContexts []string
+	if profile == "" {
+		x.Lock()
+		if x.rootDSE != nil {
+			namingContexts = x.rootDSE.NamingContexts
+		}
+		x.Unlock()
+	} else if rootDSE, err := dao.ReadRootDSE(conn); err == nil {
+		namingContexts = rootDSE.NamingContexts
+	}
+	base := dao.ParentDN(dn)
+	for _, nc := range namingContexts {
+		if nc != "" && dao.IsBelow(dn, nc) {
+			base = nc
+		}
+	}
+	return dao.NextNumber(conn, base, attr)
+}
+
+// cloneTreeSelection clones the entry selected in the directory tree
+func (x *LdapAdmin) cloneTreeSelection() {
+	if dn := x.treeSelected; dn != "" {
+		go x.cloneEntryShow(dn, "", x.windows)
+	}
+}
+
+// cloneCurrent clones the entry shown in the detail view, or the only
+// selected one
+func (t *resultTab) cloneCurrent() {
+	entry := t.detailEntry()
+	if selected := t.selectedEntries(); len(selected) == 1 {
+		entry = selected[0]
+	}
+	if entry == nil {
+		return
+	}
+	go t.x.cloneEntryShow(entry.DN, t.sourceOf(entry), t.x.resultWindow)
+}
@@ -0,0 +1,297 @@
+package app
+
+import (
+	"image/color"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
+)
+
+// commandScope tells in which window a command is available
+type commandScope int
+
+const (
+	scopeApp     commandScope = iota // 两个窗口都可用
+	scopeMain                        // 只在主窗口
+	scopeResults                     // 作用于当前结果标签, 只在结果窗口
+)
+
+// command is an action of the app, listed in the command palette and run by
+// its shortcut when it has one.
+type command struct {
+	name     string
+	shortcut *desktop.CustomShortcut
+	scope    commandScope
+	run      func()
+}
+
+// withShortcutKey is the platform's shortcut modifier, Ctrl or Cmd
+const withShortcutKey = fyne.KeyModifierShortcutDefault
+
+// key creates a shortcut, without modifiers it only works while no entry has the focus
+func key(name fyne.KeyName, modifier fyne.KeyModifier) *desktop.CustomShortcut {
+	return &desktop.CustomShortcut{KeyName: name, Modifier: modifier}
+}
+
+// commands lists everything the app can do from the keyboard
+func (x *LdapAdmin) commands() []command {
+	return []command{
+		{"Search", key(fyne.KeyReturn, withShortcutKey), scopeApp, x.searchIfValid},
+		{"Search Profiles...", nil, scopeApp, x.FederatedSearchShow},
+		{"Focus Search Filter", key(fyne.KeyL, withShortcutKey), scopeMain, func() { x.windows.Canvas().Focus(x.filterEntry) }},
+		{"New Entry...", nil, scopeMain, x.NewEntryShow},
+		{"Save Search...", nil, scopeMain, x.saveCurrentSearch},
+		{"Run Saved Search", nil, scopeMain, x.runSavedSearch},
+		{"Import Saved Searches...", nil, scopeMain, x.importSavedSearches},
+		{"Export Saved Searches...", nil, scopeMain, x.exportSavedSearches},
+		{"Save Profile...", nil, scopeMain, x.saveProfile},
+		{"Refresh Directory Tree", nil, scopeMain, x.refreshTree},
+		{"Clone Tree Selection...", nil, scopeMain, x.cloneTreeSelection},
+		{"Rename or Move Tree Selection...", nil, scopeMain, x.moveTreeSelection},
+		{"Delete Tree Selection...", nil, scopeMain, x.deleteTreeSelection},
+		{"Reload Server Info", nil, scopeApp, func() { go x.reloadServerInfo() }},
+		{"Show Schema", nil, scopeApp, x.SchemaShow},
+		// run 为空, 由 addShortcuts 打开所在窗口的命令面板
+		{"Command Palette", key(fyne.KeyP, withShortcutKey|fyne.KeyModifierShift), scopeApp, nil},
+
+		{"Focus Result Filter", key(fyne.KeyL, withShortcutKey), scopeResults, x.onTab(func(t *resultTab) {
+			x.resultWindow.Canvas().Focus(t.filterEntry)
+		})},
+		{"Edit Entry...", key(fyne.KeyF2, 0), scopeResults, x.onTab(func(t *resultTab) {
+			if entry := t.detailEntry(); entry != nil {
+				t.showEditDialog(entry)
+			}
+		})},
+		{"Compare...", nil, scopeResults, x.onTab(func(t *resultTab) {
+			if entry := t.detailEntry(); entry != nil {
+				t.showCompareDialog(entry)
+			}
+		})},
+		{"Clone Entry...", nil, scopeResults, x.onTab((*resultTab).cloneCurrent)},
+		{"Rename or Move Entry...", key(fyne.KeyF6, 0), scopeResults, x.onTab((*resultTab).moveCurrent)},
+		{"Delete...", key(fyne.KeyDelete, 0), scopeResults, x.onTab((*resultTab).deleteCurrent)},
+		{"Refresh Results", key(fyne.KeyF5, 0), scopeResults, x.onTab(func(t *resultTab) { t.rerun(true) })},
+		{"Next Page", key(fyne.KeyPageDown, fyne.KeyModifierAlt), scopeResults, x.onTab(func(t *resultTab) {
+			if t.hasNextPage() {
+				t.rerun(false)
+			}
+		})},
+		{"Previous Page", key(fyne.KeyPageUp, fyne.KeyModifierAlt), scopeResults, x.onTab((*resultTab).previousPage)},
+		{"Select All", nil, scopeResults, x.onTab((*resultTab).selectAll)},
+		{"Toggle Table View", nil, scopeResults, x.onTab(func(t *resultTab) {
+			t.tableMode = !t.tableMode
+			t.refresh()
+		})},
+		{"Choose Columns...", nil, scopeResults, x.onTab((*resultTab).showColumnsDialog)},
+		{"Export Selection as LDIF...", nil, scopeResults, x.onTab((*resultTab).exportSelection)},
+		{"Add Selection to Group...", nil, scopeResults, x.onTab((*resultTab).addSelectionToGroup)},
+		{"Modify Selection...", nil, scopeResults, x.onTab((*resultTab).modifySelection)},
+		{"Pin or Unpin Tab", nil, scopeResults, x.onTab((*resultTab).togglePin)},
+		{"Rename Tab...", nil, scopeResults, x.onTab((*resultTab).rename)},
+		{"Close Tab", nil, scopeResults, x.onTab(x.closeTab)},
+	}
+}
+
+// onTab runs an action on the current result tab, when there is one
+func (x *LdapAdmin) onTab(action func(t *resultTab)) func() {
+	return func() {
+		if t := x.currentTab(); t != nil {
+			action(t)
+		}
+	}
+}
+
+// searchIfValid searches unless the filter is invalid, like the search button
+func (x *LdapAdmin) searchIfValid() {
+	if !x.searchButton.Disabled() {
+		x.Search()
+	}
+}
+
+// windowCommands returns the commands available in a window
+func (x *LdapAdmin) windowCommands(w fyne.Window) []command {
+	scope := scopeMain
+	if w == x.resultWindow {
+		scope = scopeResults
+	}
+	var res []command
+	for _, cmd := range x.commands() {
+		if cmd.scope == scopeApp || cmd.scope == scope {
+			res = append(res, cmd)
+		}
+	}
+	return res
+}
+
+// addShortcuts registers the shortcuts of the commands of a window
+func (x *LdapAdmin) addShortcuts(w fyne.Window) {
+	c := w.Canvas()
+	for _, cmd := range x.windowCommands(w) {
+		if cmd.shortcut == nil {
+			continue
+		}
+		run := cmd.run
+		if run == nil {
+			run = func() { x.showCommandPalette(w) }
+		}
+		ext.AddShortcut(c, cmd.shortcut, func(fyne.Shortcut) { run() })
+	}
+	ext.HandleKeys(c)
+}
+
+// shortcutText shows a shortcut the way menus do, e.g. Ctrl+Shift+P
+func shortcutText(s *desktop.CustomShortcut) string {
+	if s == nil {
+		return ""
+	}
+	var parts []string
+	for _, mod := range []struct {
+		modifier fyne.KeyModifier
+		name     string
+	}{
+		{fyne.KeyModifierControl, "Ctrl"},
+		{fyne.KeyModifierSuper, "Cmd"},
+		{fyne.KeyModifierAlt, "Alt"},
+		{fyne.KeyModifierShift, "Shift"},
+	} {
+		if s.Modifier&mod.modifier != 0 {
+			parts = append(parts, mod.name)
+		}
+	}
+	name := string(s.KeyName)
+	if s.KeyName == fyne.KeyReturn {
+		name = "Enter"
+	}
+	return strings.Join(append(parts, name), "+")
+}
+
+// fuzzyScore matches the letters of pattern in order anywhere in text, case
+// insensitive. Matches at word starts and runs of letters score higher.
+func fuzzyScore(pattern, text string) (score int, ok bool) {
+	p := []rune(strings.ToLower(strings.ReplaceAll(pattern, " ", "")))
+	if len(p) == 0 {
+		return 0, true
+	}
+	t := []rune(text)
+	i, run := 0, 0
+	for j, r := range t {
+		if i == len(p) {
+			break
+		}
+		if unicode.ToLower(r) != p[i] {
+			run = 0
+			continue
+		}
+		score++
+		if j == 0 || !unicode.IsLetter(t[j-1]) || unicode.IsUpper(r) {
+			score += 3 // 单词开头
+		}
+		run++
+		score += run
+		i++
+	}
+	return score, i == len(p)
+}
+
+// showCommandPalette lists the commands of a window, typing narrows them
+// down. Enter runs the highlighted one, Up and Down move the highlight.
+func (x *LdapAdmin) showCommandPalette(w fyne.Window) {
+	var all []command
+	for _, cmd := range x.windowCommands(w) {
+		if cmd.run != nil {
+			all = append(all, cmd)
+		}
+	}
+	shown := all
+	current := 0
+
+	var popup *widget.PopUp
+	run := func(id int) {
+		if id < 0 || id >= len(shown) {
+			return
+		}
+		popup.Hide()
+		shown[id].run()
+	}
+
+	list := ext.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			hint := widget.NewLabel("")
+			hint.Importance = widget.LowImportance
+			return container.NewStack(
+				canvas.NewRectangle(color.Transparent),
+				container.NewHBox(widget.NewLabel("Template"), layout.NewSpacer(), hint),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*fyne.Container)
+			bg := row.Objects[0].(*canvas.Rectangle)
+			bg.FillColor = color.Transparent
+			if id == current {
+				bg.FillColor = theme.Color(theme.ColorNameSelection)
+			}
+			bg.Refresh()
+			box := row.Objects[1].(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(shown[id].name)
+			box.Objects[2].(*widget.Label).SetText(shortcutText(shown[id].shortcut))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.Unselect(id)
+		run(id)
+	}
+
+	entry := ext.NewEntry()
+	entry.SetPlaceHolder("Type a command")
+	entry.OnChanged = func(text string) {
+		type match struct {
+			cmd   command
+			score int
+		}
+		var matches []match
+		for _, cmd := range all {
+			if score, ok := fuzzyScore(text, cmd.name); ok {
+				matches = append(matches, match{cmd, score})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+		shown = make([]command, 0, len(matches))
+		for _, m := range matches {
+			shown = append(shown, m.cmd)
+		}
+		current = 0
+		list.Refresh()
+		list.ScrollToTop()
+	}
+	entry.OnSubmitted = func(string) { run(current) }
+	entry.OnKey = func(key fyne.KeyName) bool {
+		switch key {
+		case fyne.KeyEscape:
+			popup.Hide()
+		case fyne.KeyDown:
+			current = min(current+1, len(shown)-1)
+		case fyne.KeyUp:
+			current = max(current-1, 0)
+		default:
+			return false
+		}
+		list.Refresh()
+		list.ScrollTo(current)
+		return true
+	}
+
+	popup = widget.NewModalPopUp(container.NewBorder(entry, nil, nil, nil, list), w.Canvas())
+	popup.Resize(fyne.NewSize(450, 400))
+	popup.Show()
+	w.Canvas().Focus(entry)
+}
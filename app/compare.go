@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// 常用于比较但通常不可读的属性
+var compareAttributes = []string{"userPassword", "memberUid", "member", "uniqueMember", "objectClass"}
+
+// showCompareDialog runs an LDAP Compare on an entry, which also works for
+// attributes the user is not allowed to read.
+func (t *resultTab) showCompareDialog(entry *ldap.Entry) {
+	options := append([]string{}, compareAttributes...)
+	for _, attr := range entry.Attributes {
+		if !containsFold(options, attr.Name) {
+			options = append(options, attr.Name)
+		}
+	}
+	sort.Strings(options)
+
+	valueEntry := widget.NewEntry()
+	attrEntry := widget.NewSelectEntry(options)
+	attrEntry.OnChanged = func(attr string) {
+		// 不在屏幕上显示密码
+		valueEntry.Password = strings.Contains(strings.ToLower(attr), "password")
+		valueEntry.Refresh()
+	}
+	attrEntry.SetText("userPassword")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("DN", widget.NewLabel(entry.DN)),
+		widget.NewFormItem("Attribute", attrEntry),
+		widget.NewFormItem("Value", valueEntry),
+	}
+	parent := t.x.resultWindow
+	form := dialog.NewForm("Compare", "Compare", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		attr := strings.TrimSpace(attrEntry.Text)
+		if attr == "" {
+			dialog.ShowError(fmt.Errorf("attribute is required"), parent)
+			return
+		}
+		go t.compare(entry, attr, valueEntry.Text, parent)
+	}, parent)
+	form.Resize(fyne.NewSize(500, 250))
+	form.Show()
+}
+
+func (t *resultTab) compare(entry *ldap.Entry, attr, value string, parent fyne.Window) {
+	conn, release, err := t.connFor(entry)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	defer release()
+
+	matched, err := dao.Compare(conn, entry.DN, attr, value)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	shown := value
+	if strings.Contains(strings.ToLower(attr), "password") {
+		shown = "(hidden)"
+	}
+	if matched {
+		dialog.ShowInformation("compareTrue", fmt.Sprintf("%s has %s = %s", entry.DN, attr, shown), parent)
+	} else {
+		dialog.ShowInformation("compareFalse", fmt.Sprintf("%s does not have %s = %s", entry.DN, attr, shown), parent)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
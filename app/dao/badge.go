@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Badge is a state of an entry shown next to its name
+type Badge string
+
+const (
+	BadgeDisabled Badge = "disabled"
+	BadgeLocked   Badge = "locked"
+	BadgeAlias    Badge = "alias"
+	BadgeChildren Badge = "children"
+)
+
+// Active Directory userAccountControl flags
+const (
+	uacAccountDisable = 0x2
+	uacLockout        = 0x10
+)
+
+// BadgeAttributes are the attributes EntryBadges looks at. Several are
+// operational and only returned when requested by name.
+var BadgeAttributes = []string{
+	"objectClass",
+	"userAccountControl",   // AD
+	"lockoutTime",          // AD
+	"nsAccountLock",        // 389 DS
+	"pwdAccountLockedTime", // OpenLDAP ppolicy
+	"loginDisabled",        // eDirectory
+	"aliasedObjectName",
+	"hasSubordinates",
+	"numSubordinates",
+}
+
+// EntryBadges returns the states of an entry that its attributes tell about.
+// Unlike HasChildren, children are only reported when the server says so.
+func EntryBadges(e *ldap.Entry) []Badge {
+	var res []Badge
+	uac, _ := strconv.ParseInt(e.GetEqualFoldAttributeValue("userAccountControl"), 10, 64)
+	if uac&uacAccountDisable != 0 ||
+		strings.EqualFold(e.GetEqualFoldAttributeValue("nsAccountLock"), "TRUE") ||
+		strings.EqualFold(e.GetEqualFoldAttributeValue("loginDisabled"), "TRUE") {
+		res = append(res, BadgeDisabled)
+	}
+	lockout, _ := strconv.ParseInt(e.GetEqualFoldAttributeValue("lockoutTime"), 10, 64)
+	if uac&uacLockout != 0 || lockout > 0 || e.GetEqualFoldAttributeValue("pwdAccountLockedTime") != "" {
+		res = append(res, BadgeLocked)
+	}
+	if e.GetEqualFoldAttributeValue("aliasedObjectName") != "" || hasClass(e, "alias") {
+		res = append(res, BadgeAlias)
+	}
+	if v := e.GetEqualFoldAttributeValue("hasSubordinates"); strings.EqualFold(v, "TRUE") {
+		res = append(res, BadgeChildren)
+	} else if n, _ := strconv.Atoi(e.GetEqualFoldAttributeValue("numSubordinates")); v == "" && n > 0 {
+		res = append(res, BadgeChildren)
+	}
+	return res
+}
+
+func hasClass(e *ldap.Entry, class string) bool {
+	for _, c := range e.GetEqualFoldAttributeValues("objectClass") {
+		if strings.EqualFold(c, class) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package dao
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BinaryKind tells how the values of an attribute are shown
+type BinaryKind int
+
+const (
+	NotBinary BinaryKind = iota
+	BinaryImage
+	BinaryCertificate
+	BinaryGUID
+	BinarySID
+	BinaryOther
+)
+
+// 按属性名识别, 适用于 schema 里只声明为 Octet String 的属性
+var binaryAttributes = map[string]BinaryKind{
+	"jpegphoto":             BinaryImage,
+	"photo":                 BinaryImage,
+	"thumbnailphoto":        BinaryImage,
+	"usercertificate":       BinaryCertificate,
+	"cacertificate":         BinaryCertificate,
+	"usersmimecertificate":  BinaryCertificate,
+	"objectguid":            BinaryGUID,
+	"msexchmailboxguid":     BinaryGUID,
+	"ms-ds-consistencyguid": BinaryGUID,
+	"objectsid":             BinarySID,
+	"sidhistory":            BinarySID,
+	"securityidentifier":    BinarySID,
+}
+
+// binarySyntaxes are the RFC 4517 syntaxes whose values are not text
+var binarySyntaxes = map[string]BinaryKind{
+	"1.3.6.1.4.1.1466.115.121.1.28": BinaryImage,       // JPEG
+	"1.3.6.1.4.1.1466.115.121.1.8":  BinaryCertificate, // Certificate
+	"1.3.6.1.4.1.1466.115.121.1.4":  BinaryOther,       // Audio
+	"1.3.6.1.4.1.1466.115.121.1.5":  BinaryOther,       // Binary
+	"1.3.6.1.4.1.1466.115.121.1.9":  BinaryOther,       // Certificate List
+	"1.3.6.1.4.1.1466.115.121.1.10": BinaryOther,       // Certificate Pair
+	"1.3.6.1.4.1.1466.115.121.1.23": BinaryOther,       // Fax
+	"1.3.6.1.4.1.1466.115.121.1.40": BinaryOther,       // Octet String
+	"1.3.6.1.4.1.1466.115.121.1.49": BinaryOther,       // Supported Algorithm
+}
+
+// BinaryKindOf tells whether an attribute holds binary values, judging by its
+// name, a ;binary option and its syntax in the schema, which may be nil.
+func BinaryKindOf(s *Schema, attr string) BinaryKind {
+	name, options, _ := strings.Cut(attr, ";")
+	if kind, ok := binaryAttributes[strings.ToLower(name)]; ok {
+		return kind
+	}
+	if s != nil {
+		if kind, ok := binarySyntaxes[s.AttributeSyntax(name)]; ok {
+			return kind
+		}
+	}
+	for _, option := range strings.Split(options, ";") {
+		if strings.EqualFold(option, "binary") {
+			return BinaryOther
+		}
+	}
+	return NotBinary
+}
+
+// FormatGUID formats a Microsoft GUID, whose first three parts are little endian
+func FormatGUID(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", fmt.Errorf("GUID has %d bytes instead of 16", len(b))
+	}
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10], b[10:16]), nil
+}
+
+// FormatSID formats a Windows security identifier as S-1-5-21-...
+func FormatSID(b []byte) (string, error) {
+	if len(b) < 8 {
+		return "", fmt.Errorf("SID has only %d bytes", len(b))
+	}
+	count := int(b[1])
+	if len(b) != 8+4*count {
+		return "", fmt.Errorf("SID has %d bytes, expected %d", len(b), 8+4*count)
+	}
+	// 48 位大端的 identifier authority
+	var authority uint64
+	for _, c := range b[2:8] {
+		authority = authority<<8 | uint64(c)
+	}
+	var sid strings.Builder
+	fmt.Fprintf(&sid, "S-%d-%d", b[0], authority)
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&sid, "-%d", binary.LittleEndian.Uint32(b[8+4*i:]))
+	}
+	return sid.String(), nil
+}
+
+// DescribeCertificate summarizes a DER encoded X.509 certificate
+func DescribeCertificate(b []byte) (string, error) {
+	cert, err := x509.ParseCertificate(b)
+	if err != nil {
+		return "", fmt.Errorf("parse certificate: %w", err)
+	}
+	var desc strings.Builder
+	fmt.Fprintf(&desc, "Subject: %s\n", cert.Subject)
+	fmt.Fprintf(&desc, "Issuer: %s\n", cert.Issuer)
+	fmt.Fprintf(&desc, "Serial: %s\n", cert.SerialNumber.Text(16))
+	fmt.Fprintf(&desc, "Valid: %s to %s", cert.NotBefore.Format(time.DateTime), cert.NotAfter.Format(time.DateTime))
+	now := time.Now()
+	switch {
+	case now.After(cert.NotAfter):
+		desc.WriteString(" (expired)")
+	case now.Before(cert.NotBefore):
+		desc.WriteString(" (not yet valid)")
+	}
+	return desc.String(), nil
+}
+
+// FormatBinary shows a value as text when it is printable, as hex when it
+// is short and as base64 otherwise.
+func FormatBinary(b []byte) string {
+	if IsPrintable(b) {
+		return string(b)
+	}
+	if len(b) <= 32 {
+		return "hex: " + hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("base64 (%d bytes): %s", len(b), base64.StdEncoding.EncodeToString(b))
+}
+
+// IsPrintable reports whether a value is UTF-8 text without control characters
+func IsPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package dao
+
+import "testing"
+
+func TestFormatGUID(t *testing.T) {
+	b := []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	got, err := FormatGUID(b)
+	if err != nil || got != "00112233-4455-6677-8899-aabbccddeeff" {
+		t.Errorf("FormatGUID = %q, %v", got, err)
+	}
+	if _, err := FormatGUID(b[:15]); err == nil {
+		t.Error("FormatGUID accepted 15 bytes")
+	}
+}
+
+func TestFormatSID(t *testing.T) {
+	tests := []struct {
+		b     []byte
+		want  string
+		fails bool
+	}{
+		// S-1-5-32-544, BUILTIN\Administrators
+		{b: []byte{1, 2, 0, 0, 0, 0, 0, 5, 32, 0, 0, 0, 0x20, 0x02, 0, 0}, want: "S-1-5-32-544"},
+		// S-1-1-0, Everyone
+		{b: []byte{1, 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0}, want: "S-1-1-0"},
+		{b: []byte{1, 2, 0, 0, 0, 0, 0, 5, 32, 0, 0, 0}, fails: true},
+		{b: []byte{1, 0, 0}, fails: true},
+	}
+	for _, tt := range tests {
+		got, err := FormatSID(tt.b)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("FormatSID(% x) = %q, want an error", tt.b, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("FormatSID(% x) = %q, %v, want %q", tt.b, got, err, tt.want)
+		}
+	}
+}
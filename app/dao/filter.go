@@ -0,0 +1,227 @@
+package dao
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// FilterError describes a syntax error in a search filter.
+// Pos is the character offset in the filter where the problem was found,
+// or -1 when the error has no position.
+type FilterError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	if e.Pos < 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("position %d: %s", e.Pos+1, e.Msg)
+}
+
+// ParseFilter checks the syntax of an RFC 4515 filter and returns the
+// attribute names it references, without any options such as ";binary".
+func ParseFilter(filter string) (attrs []string, err error) {
+	p := &filterParser{s: filter}
+	if err = p.parseFilter(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after the end of the filter", p.s[p.pos:])
+	}
+	// go-ldap has the final say, we only parse to get positions
+	if _, err = ldap.CompileFilter(filter); err != nil {
+		return nil, &FilterError{Pos: -1, Msg: err.Error()}
+	}
+	return p.attrs, nil
+}
+
+type filterParser struct {
+	s     string
+	pos   int
+	attrs []string
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) *FilterError {
+	return p.errorAt(p.pos, fmt.Sprintf(format, args...))
+}
+
+// errorAt reports an error at a byte offset, counted in characters
+func (p *filterParser) errorAt(pos int, msg string) *FilterError {
+	return &FilterError{Pos: utf8.RuneCountInString(p.s[:pos]), Msg: msg}
+}
+
+// current returns the whole character at the position, for messages
+func (p *filterParser) current() rune {
+	r, _ := utf8.DecodeRuneInString(p.s[p.pos:])
+	return r
+}
+
+func (p *filterParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+// parseFilter parses "(" filtercomp ")"
+func (p *filterParser) parseFilter() error {
+	if p.eof() {
+		return p.errorf("expected '(' but the filter ended")
+	}
+	if p.s[p.pos] != '(' {
+		return p.errorf("expected '(' but found %q", p.current())
+	}
+	open := p.pos
+	p.pos++
+	if p.eof() {
+		return p.errorf("unclosed '(' opened at position %d", utf8.RuneCountInString(p.s[:open])+1)
+	}
+	var err error
+	switch p.s[p.pos] {
+	case '&', '|':
+		p.pos++
+		for !p.eof() && p.s[p.pos] == '(' {
+			if err = p.parseFilter(); err != nil {
+				return err
+			}
+		}
+	case '!':
+		p.pos++
+		err = p.parseFilter()
+	default:
+		err = p.parseItem()
+	}
+	if err != nil {
+		return err
+	}
+	if p.eof() {
+		return p.errorAt(open, "unclosed '('")
+	}
+	if p.s[p.pos] != ')' {
+		return p.errorf("expected ')' but found %q", p.current())
+	}
+	p.pos++
+	return nil
+}
+
+// parseItem parses a simple, presence, substring or extensible match
+func (p *filterParser) parseItem() error {
+	start := p.pos
+	for !p.eof() && isAttrChar(p.s[p.pos]) {
+		p.pos++
+	}
+	attr := p.s[start:p.pos]
+	if attr != "" {
+		p.attrs = append(p.attrs, strings.SplitN(attr, ";", 2)[0])
+	}
+	if p.eof() {
+		return p.errorf("expected a filter type such as '=' but the filter ended")
+	}
+
+	switch p.s[p.pos] {
+	case '=':
+		p.pos++
+	case '~', '>', '<':
+		p.pos++
+		if p.eof() || p.s[p.pos] != '=' {
+			return p.errorf("expected '=' after %q", p.s[p.pos-1])
+		}
+		p.pos++
+	case ':':
+		if err := p.parseExtensible(attr == ""); err != nil {
+			return err
+		}
+	default:
+		if attr == "" {
+			return p.errorf("expected an attribute name but found %q", p.current())
+		}
+		return p.errorf("invalid character %q in attribute name", p.current())
+	}
+	if attr == "" && p.s[start] != ':' {
+		return p.errorAt(start, "missing attribute name")
+	}
+	return p.parseValue()
+}
+
+// parseExtensible parses [":dn"] [":" matchingrule] ":="
+func (p *filterParser) parseExtensible(noAttr bool) error {
+	hasRule := false
+	for !p.eof() && p.s[p.pos] == ':' {
+		p.pos++
+		if !p.eof() && p.s[p.pos] == '=' {
+			p.pos++
+			if noAttr && !hasRule {
+				return p.errorf("extensible match without an attribute needs a matching rule")
+			}
+			return nil
+		}
+		start := p.pos
+		for !p.eof() && isAttrChar(p.s[p.pos]) {
+			p.pos++
+		}
+		if start == p.pos {
+			return p.errorf("expected 'dn', a matching rule or '='")
+		}
+		if !strings.EqualFold(p.s[start:p.pos], "dn") {
+			hasRule = true
+		}
+	}
+	return p.errorf("expected ':=' in extensible match")
+}
+
+// parseValue parses an assertion value up to the closing ')'
+func (p *filterParser) parseValue() error {
+	for !p.eof() {
+		switch c := p.s[p.pos]; c {
+		case ')':
+			return nil
+		case '(':
+			return p.errorf("unescaped '(' in value, use \\28")
+		case '\\':
+			if p.pos+2 >= len(p.s) || !isHex(p.s[p.pos+1]) || !isHex(p.s[p.pos+2]) {
+				return p.errorf("'\\' must be followed by two hex digits")
+			}
+			p.pos += 3
+		default:
+			p.pos++
+		}
+	}
+	return nil
+}
+
+func isAttrChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == ';'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// QuickSearchFilter builds a filter that looks for free text in several
+// attributes. Every word has to appear in at least one of the attributes, so
+// "zhang wei" finds cn=Wei Zhang as well as uid=zhangwei.
+func QuickSearchFilter(text string, attrs []string) string {
+	words := strings.Fields(text)
+	if len(words) == 0 || len(attrs) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(words))
+	for _, word := range words {
+		value := "*" + ldap.EscapeFilter(word) + "*"
+		var or strings.Builder
+		for _, attr := range attrs {
+			fmt.Fprintf(&or, "(%s=%s)", attr, value)
+		}
+		if len(attrs) == 1 {
+			parts = append(parts, or.String())
+		} else {
+			parts = append(parts, "(|"+or.String()+")")
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(&" + strings.Join(parts, "") + ")"
+}
@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		attrs  []string
+		pos    int // -1: 没有位置
+		msg    string
+	}{
+		{filter: "(uid=zhang)", attrs: []string{"uid"}},
+		{filter: "(&(objectClass=person)(|(cn=a*)(sn~=b)))", attrs: []string{"objectClass", "cn", "sn"}},
+		{filter: "(!(mail=*))", attrs: []string{"mail"}},
+		{filter: "(cn;lang-en>=m)", attrs: []string{"cn"}},
+		{filter: "(cn:caseExactMatch:=Wei)", attrs: []string{"cn"}},
+		{filter: "(:dn:2.5.13.5:=x)", attrs: nil},
+		{filter: `(cn=a\28b\29)`, attrs: []string{"cn"}},
+		{filter: "(cn=张伟)", attrs: []string{"cn"}},
+
+		{filter: "", pos: 0, msg: "expected '(' but the filter ended"},
+		{filter: "uid=x", pos: 0, msg: `expected '(' but found 'u'`},
+		{filter: "(uid=x", pos: 0, msg: "unclosed '('"},
+		{filter: "(uid=x))", pos: 7, msg: `unexpected ")" after the end of the filter`},
+		{filter: "(=x)", pos: 1, msg: "missing attribute name"},
+		{filter: "(uid>x)", pos: 5, msg: `expected '=' after '>'`},
+		{filter: "(cn=a(b)", pos: 5, msg: `unescaped '(' in value, use \28`},
+		{filter: `(cn=a\2)`, pos: 5, msg: `'\' must be followed by two hex digits`},
+		{filter: "(:=x)", pos: 3, msg: "extensible match without an attribute needs a matching rule"},
+		// 位置按字符计, 消息中是完整的字符
+		{filter: "(张=x)", pos: 1, msg: `expected an attribute name but found '张'`},
+		{filter: "(cn=张伟)(", pos: 7, msg: `unexpected "(" after the end of the filter`},
+		{filter: "(c张=x)", pos: 2, msg: `invalid character '张' in attribute name`},
+	}
+	for _, tt := range tests {
+		attrs, err := ParseFilter(tt.filter)
+		if tt.msg == "" {
+			if err != nil {
+				t.Errorf("ParseFilter(%q): %v", tt.filter, err)
+			} else if !slices.Equal(attrs, tt.attrs) {
+				t.Errorf("ParseFilter(%q) = %q, want %q", tt.filter, attrs, tt.attrs)
+			}
+			continue
+		}
+		var filterErr *FilterError
+		if !errors.As(err, &filterErr) {
+			t.Errorf("ParseFilter(%q): got %v, want a *FilterError", tt.filter, err)
+			continue
+		}
+		if filterErr.Pos != tt.pos || filterErr.Msg != tt.msg {
+			t.Errorf("ParseFilter(%q): got position %d %q, want %d %q",
+				tt.filter, filterErr.Pos, filterErr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestFilterErrorWithoutPosition(t *testing.T) {
+	err := &FilterError{Pos: -1, Msg: "bad filter"}
+	if got := err.Error(); got != "bad filter" {
+		t.Errorf("Error() = %q, want %q", got, "bad filter")
+	}
+	err = &FilterError{Pos: 2, Msg: "bad filter"}
+	if got := err.Error(); got != "position 3: bad filter" {
+		t.Errorf("Error() = %q, want %q", got, "position 3: bad filter")
+	}
+}
@@ -2,7 +2,12 @@ package dao
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
@@ -12,8 +17,16 @@ import (
 type LDAPPool struct {
 	connections chan *ldap.Conn
 	config      *LDAPConfig
+	mu          sync.Mutex
+	closed      bool // 关闭后归还的连接直接关闭
 }
 
+const (
+	ScopeBase = "base"
+	ScopeOne  = "one"
+	ScopeSub  = "sub"
+)
+
 type LDAPConfig struct {
 	Server   string
 	Port     string
@@ -65,10 +78,38 @@ func (p *LDAPPool) GetConnection() (*ldap.Conn, error) {
 	}
 }
 
-// ReleaseConnection returns a connection to the pool
+// ReleaseConnection returns a connection to the pool. It never blocks: the
+// connection is closed instead when the pool is closed or already full.
 func (p *LDAPPool) ReleaseConnection(conn *ldap.Conn) {
-	if conn != nil {
-		p.connections <- conn
+	if conn == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		_ = conn.Close()
+		return
+	}
+	select {
+	case p.connections <- conn:
+	default:
+		_ = conn.Close()
+	}
+}
+
+// Close closes the idle connections of the pool, connections in use are
+// closed when they are released
+func (p *LDAPPool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	for {
+		select {
+		case conn := <-p.connections:
+			_ = conn.Close()
+		default:
+			return
+		}
 	}
 }
 
@@ -121,20 +162,55 @@ func (p *LDAPPool) validateConnection(conn *ldap.Conn) error {
 	return err
 }
 
-// Search performs an LDAP search with improved error handling and attribute filtering
-func Search(l *ldap.Conn, baseDN, filter string, control *ldap.ControlPaging, attributes []string) (entries []*ldap.Entry, err error) {
+// SearchOptions describes what a search should return
+type SearchOptions struct {
+	BaseDN     string
+	Filter     string
+	Scope      int
+	Attributes []string
+	SizeLimit  int // 0 表示不限制
+	TimeLimit  int // 秒, 0 表示不限制
+}
+
+// TruncatedError is returned together with the entries received before the
+// server stopped a search because of its size or time limit.
+type TruncatedError struct {
+	Err error
+}
+
+func (e *TruncatedError) Error() string {
+	if ldap.IsErrorWithCode(e.Err, ldap.LDAPResultTimeLimitExceeded) {
+		return "time limit exceeded, results are truncated"
+	}
+	return "size limit exceeded, results are truncated"
+}
+
+func (e *TruncatedError) Unwrap() error {
+	return e.Err
+}
+
+// IsTruncated reports whether err only means that the results are incomplete
+func IsTruncated(err error) bool {
+	var truncated *TruncatedError
+	return errors.As(err, &truncated)
+}
+
+// Search performs an LDAP search with improved error handling and attribute filtering.
+// When a size or time limit is hit, the partial entries are returned with a *TruncatedError.
+func Search(l *ldap.Conn, opts *SearchOptions, control *ldap.ControlPaging) (entries []*ldap.Entry, err error) {
+	attributes := opts.Attributes
 	if attributes == nil {
 		attributes = []string{"*"} // Default to all attributes
 	}
 
 	searchRequest := ldap.NewSearchRequest(
-		baseDN,
-		ldap.ScopeWholeSubtree,
+		opts.BaseDN,
+		opts.Scope,
 		ldap.NeverDerefAliases,
-		0,
-		int(control.PagingSize),
+		opts.SizeLimit,
+		opts.TimeLimit,
 		false,
-		filter,
+		opts.Filter,
 		attributes,
 		[]ldap.Control{control},
 	)
@@ -142,11 +218,13 @@ func Search(l *ldap.Conn, baseDN, filter string, control *ldap.ControlPaging, at
 	var sr *ldap.SearchResult
 	sr, err = l.Search(searchRequest)
 	if err != nil {
-		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
-			// Handle size limit exceeded gracefully
-			return sr.Entries, nil
+		if sr != nil && (ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) ||
+			ldap.IsErrorWithCode(err, ldap.LDAPResultTimeLimitExceeded)) {
+			// keep what was received, there are no further pages
+			control.SetCookie(nil)
+			return sr.Entries, &TruncatedError{Err: err}
 		}
-		return nil, fmt.Errorf("LDAP search failed: %v", err)
+		return nil, fmt.Errorf("LDAP search failed: %w", err)
 	}
 
 	for _, c := range sr.Controls {
@@ -159,6 +237,56 @@ func Search(l *ldap.Conn, baseDN, filter string, control *ldap.ControlPaging, at
 	return sr.Entries, nil
 }
 
+// ParseScope converts base, one or sub to an LDAP scope, defaulting to sub
+func ParseScope(scope string) int {
+	switch scope {
+	case ScopeBase:
+		return ldap.ScopeBaseObject
+	case ScopeOne:
+		return ldap.ScopeSingleLevel
+	default:
+		return ldap.ScopeWholeSubtree
+	}
+}
+
+// SortEntries sorts entries by the first value of an attribute, ignoring case.
+// A leading "-" sorts in descending order, "dn" sorts by DN. Attributes with
+// the INTEGER syntax sort by number when the schema is known.
+func SortEntries(entries []*ldap.Entry, by string, schema *Schema) {
+	attr := strings.TrimPrefix(by, "-")
+	if attr == "" {
+		return
+	}
+	key := func(e *ldap.Entry) string {
+		if strings.EqualFold(attr, "dn") {
+			return e.DN
+		}
+		return e.GetAttributeValue(attr)
+	}
+	SortEntriesBy(entries, key, strings.HasPrefix(by, "-"), schema.IsInteger(attr))
+}
+
+// SortEntriesBy sorts entries by a key, as numbers when numeric is set and
+// both keys are numbers, otherwise as text ignoring case.
+func SortEntriesBy(entries []*ldap.Entry, key func(e *ldap.Entry) string, desc, numeric bool) {
+	less := func(a, b string) bool {
+		if numeric {
+			x, errX := strconv.ParseInt(strings.TrimSpace(a), 10, 64)
+			y, errY := strconv.ParseInt(strings.TrimSpace(b), 10, 64)
+			if errX == nil && errY == nil {
+				return x < y
+			}
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if desc {
+			return less(key(entries[j]), key(entries[i]))
+		}
+		return less(key(entries[i]), key(entries[j]))
+	})
+}
+
 func GetLdap(server, port, username, password string) (*ldap.Conn, error) {
 	l, err := ldap.DialURL(fmt.Sprintf("%s:%s", server, port), ldap.DialWithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
 	if err != nil {
@@ -195,3 +323,270 @@ func SearchOld(l *ldap.Conn, baseDN, filter string, control *ldap.ControlPaging)
 	entries = sr.Entries
 	return
 }
+
+// ReadEntry reads a single entry, with all user attributes when attributes is nil
+func ReadEntry(l *ldap.Conn, dn string, attributes []string) (*ldap.Entry, error) {
+	if attributes == nil {
+		attributes = []string{"*"}
+	}
+	sr, err := l.Search(ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=*)",
+		attributes,
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", dn, err)
+	}
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("read %s: entry not found", dn)
+	}
+	return sr.Entries[0], nil
+}
+
+// ListChildren returns the direct children of an entry. Only the attributes
+// for HasChildren and EntryBadges are requested.
+func ListChildren(l *ldap.Conn, dn string) ([]*ldap.Entry, error) {
+	sr, err := l.SearchWithPaging(ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=*)",
+		BadgeAttributes,
+		nil,
+	), 500)
+	if err != nil {
+		return nil, fmt.Errorf("list children of %s: %w", dn, err)
+	}
+	return sr.Entries, nil
+}
+
+// HasChildren reports whether an entry has children, judging by its
+// hasSubordinates or numSubordinates attribute. When the server provides
+// neither, it may have children and true is returned.
+func HasChildren(e *ldap.Entry) bool {
+	if v := e.GetAttributeValue("hasSubordinates"); v != "" {
+		return strings.EqualFold(v, "TRUE")
+	}
+	if v := e.GetAttributeValue("numSubordinates"); v != "" {
+		return v != "0"
+	}
+	return true
+}
+
+// ChildCount tells how many entries are directly below an entry, from its
+// numSubordinates attribute. exact is false when the server does not count
+// them: the count is then 1 when there are children, found by hasSubordinates
+// or a one-level search stopped after the first entry.
+func ChildCount(l *ldap.Conn, dn string) (count int, exact bool, err error) {
+	e, err := ReadEntry(l, dn, []string{"hasSubordinates", "numSubordinates"})
+	if err != nil {
+		return 0, false, err
+	}
+	if n, err := strconv.Atoi(e.GetAttributeValue("numSubordinates")); err == nil {
+		return n, true, nil
+	}
+	if v := e.GetAttributeValue("hasSubordinates"); v != "" {
+		if strings.EqualFold(v, "TRUE") {
+			return 1, false, nil
+		}
+		return 0, true, nil
+	}
+
+	sr, err := l.Search(ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 1, 0, false,
+		"(objectClass=*)",
+		[]string{"1.1"},
+		nil,
+	))
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return 1, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("look for entries below %s: %w", dn, err)
+	}
+	if len(sr.Entries) > 0 {
+		return 1, false, nil
+	}
+	return 0, true, nil
+}
+
+// Compare asks the server whether an entry has an attribute value, without
+// reading the attribute. It returns true for compareTrue and false for compareFalse.
+func Compare(l *ldap.Conn, dn, attribute, value string) (bool, error) {
+	matched, err := l.Compare(dn, attribute, value)
+	if err != nil {
+		return false, fmt.Errorf("compare %s on %s: %w", attribute, dn, err)
+	}
+	return matched, nil
+}
+
+// ChildDN returns the DN of an entry named attr=value below parent
+func ChildDN(parent, attr, value string) string {
+	rdn := attr + "=" + ldap.EscapeDN(value)
+	if parent == "" {
+		return rdn
+	}
+	return rdn + "," + parent
+}
+
+// SplitDN splits a DN at its first unescaped comma into the RDN and the DN of
+// the parent, without parsing and writing the DN again, so both match the DNs
+// the server returned.
+func SplitDN(dn string) (rdn, parent string) {
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '\\':
+			i++ // 转义的字符
+		case ',':
+			return strings.TrimSpace(dn[:i]), strings.TrimSpace(dn[i+1:])
+		}
+	}
+	return strings.TrimSpace(dn), ""
+}
+
+// ParentDN returns the DN an entry is below
+func ParentDN(dn string) string {
+	_, parent := SplitDN(dn)
+	return parent
+}
+
+// IsBelow reports whether dn is the same as base or lies below it, ignoring case
+func IsBelow(dn, base string) bool {
+	dn, base = strings.ToLower(dn), strings.ToLower(base)
+	return dn == base || strings.HasSuffix(dn, ","+base)
+}
+
+// Add creates an entry with the given attributes
+func Add(l *ldap.Conn, dn string, attributes []ldap.Attribute) error {
+	req := ldap.NewAddRequest(dn, nil)
+	req.Attributes = attributes
+	if err := l.Add(req); err != nil {
+		return fmt.Errorf("add %s: %w", dn, err)
+	}
+	return nil
+}
+
+// NextNumber returns one more than the highest integer value of an attribute
+// below base, such as the next free uidNumber
+func NextNumber(l *ldap.Conn, base, attr string) (int, error) {
+	sr, err := l.SearchWithPaging(ldap.NewSearchRequest(
+		base,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf("(%s=*)", attr),
+		[]string{attr},
+		nil,
+	), 500)
+	if err != nil {
+		return 0, fmt.Errorf("find the highest %s below %s: %w", attr, base, err)
+	}
+	highest := 0
+	for _, e := range sr.Entries {
+		for _, v := range e.GetAttributeValues(attr) {
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > highest {
+				highest = n
+			}
+		}
+	}
+	return highest + 1, nil
+}
+
+// Delete removes a single entry, which must not have children
+func Delete(l *ldap.Conn, dn string) error {
+	if err := l.Del(ldap.NewDelRequest(dn, nil)); err != nil {
+		return fmt.Errorf("delete %s: %w", dn, err)
+	}
+	return nil
+}
+
+// ModifyDN renames an entry to newRDN and, when newSuperior is not empty,
+// moves it below newSuperior. It returns the new DN of the entry.
+func ModifyDN(l *ldap.Conn, dn, newRDN string, deleteOldRDN bool, newSuperior string) (string, error) {
+	req := ldap.NewModifyDNRequest(dn, newRDN, deleteOldRDN, newSuperior)
+	if err := l.ModifyDN(req); err != nil {
+		return "", fmt.Errorf("rename %s: %w", dn, err)
+	}
+	parent := newSuperior
+	if parent == "" {
+		parent = ParentDN(dn)
+	}
+	if parent == "" {
+		return newRDN, nil
+	}
+	return newRDN + "," + parent, nil
+}
+
+// TreeDeleteOID is the control that makes the server delete a whole subtree
+const TreeDeleteOID = "1.2.840.113556.1.4.805"
+
+// DeleteTree deletes an entry and everything below it with the Tree Delete
+// control, which the server has to support.
+func DeleteTree(l *ldap.Conn, dn string) error {
+	req := ldap.NewDelRequest(dn, []ldap.Control{ldap.NewControlString(TreeDeleteOID, true, "")})
+	if err := l.Del(req); err != nil {
+		return fmt.Errorf("delete subtree %s: %w", dn, err)
+	}
+	return nil
+}
+
+// ListSubtree returns the DNs of an entry and all entries below it, deepest
+// first, so deleting them in order only ever deletes leaves.
+func ListSubtree(l *ldap.Conn, dn string) ([]string, error) {
+	sr, err := l.SearchWithPaging(ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=*)",
+		[]string{"1.1"}, // 不需要属性
+		nil,
+	), 500)
+	if err != nil {
+		return nil, fmt.Errorf("list subtree of %s: %w", dn, err)
+	}
+	type item struct {
+		dn    string
+		depth int
+	}
+	items := make([]item, 0, len(sr.Entries))
+	for _, e := range sr.Entries {
+		depth := strings.Count(e.DN, ",") // 解析失败时的近似值
+		if parsed, err := ldap.ParseDN(e.DN); err == nil {
+			depth = len(parsed.RDNs)
+		}
+		items = append(items, item{e.DN, depth})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].depth > items[j].depth })
+	res := make([]string, len(items))
+	for i, it := range items {
+		res[i] = it.dn
+	}
+	return res, nil
+}
+
+// AddMember adds an entry to a group. The member attribute follows the group's
+// object class: memberUid with the uid for posixGroup, uniqueMember for
+// groupOfUniqueNames and member otherwise.
+func AddMember(l *ldap.Conn, groupDN string, member *ldap.Entry) error {
+	group, err := ReadEntry(l, groupDN, []string{"objectClass"})
+	if err != nil {
+		return err
+	}
+	attr, value := "member", member.DN
+	for _, class := range group.GetAttributeValues("objectClass") {
+		switch strings.ToLower(class) {
+		case "groupofuniquenames":
+			attr = "uniqueMember"
+		case "posixgroup":
+			attr, value = "memberUid", member.GetAttributeValue("uid")
+			if value == "" {
+				return fmt.Errorf("add %s to %s: posixGroup members need a uid", member.DN, groupDN)
+			}
+		}
+	}
+	req := ldap.NewModifyRequest(groupDN, nil)
+	req.Add(attr, []string{value})
+	if err := l.Modify(req); err != nil {
+		return fmt.Errorf("add %s to %s: %w", member.DN, groupDN, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldifLineLength is where long LDIF lines are folded
+const ldifLineLength = 76
+
+// WriteLDIF writes an entry as an LDIF (RFC 2849) record, followed by an empty line
+func WriteLDIF(w io.Writer, entry *ldap.Entry) error {
+	var b strings.Builder
+	writeLDIFLine(&b, "dn", entry.DN)
+	for _, attr := range entry.Attributes {
+		values := attr.Values
+		if len(attr.ByteValues) == len(values) {
+			values = make([]string, len(attr.ByteValues))
+			for i, v := range attr.ByteValues {
+				values[i] = string(v)
+			}
+		}
+		for _, v := range values {
+			writeLDIFLine(&b, attr.Name, v)
+		}
+	}
+	b.WriteString("\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write %s: %w", entry.DN, err)
+	}
+	return nil
+}
+
+// writeLDIFLine writes one attribute value, base64 encoded when it is not a
+// safe string, and folds the line.
+func writeLDIFLine(b *strings.Builder, name, value string) {
+	line := name + ": " + value
+	if !ldifSafe(value) {
+		line = name + ":: " + base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	for len(line) > ldifLineLength {
+		b.WriteString(line[:ldifLineLength])
+		b.WriteString("\n ")
+		line = line[ldifLineLength:]
+	}
+	b.WriteString(line)
+	b.WriteString("\n")
+}
+
+// ldifSafe reports whether a value can be written as is
+func ldifSafe(value string) bool {
+	if value == "" {
+		return true
+	}
+	switch value[0] {
+	case ' ', ':', '<':
+		return false
+	}
+	if value[len(value)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == 0 || c == '\n' || c == '\r' || c >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
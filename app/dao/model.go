@@ -2,21 +2,23 @@ package dao
 
 import "fyne.io/fyne/v2/data/binding"
 
-var isTest = true
-
 type SearchReq struct {
-	Filter binding.String
-	BaseDN binding.String
+	Filter     binding.String
+	BaseDN     binding.String
+	Scope      binding.String // base, one 或 sub
+	Attributes binding.String // 逗号分隔, 为空时使用默认属性
+	Sort       binding.String // 排序属性, 以 - 开头表示倒序
 }
 
 func NewSearchReq() *SearchReq {
 	res := &SearchReq{
-		Filter: binding.NewString(),
-		BaseDN: binding.NewString(),
-	}
-	if isTest {
-		res.Filter.Set("(uid=wanna*)")
-		res.BaseDN.Set("dc=wanna,dc=com")
+		Filter:     binding.NewString(),
+		BaseDN:     binding.NewString(),
+		Scope:      binding.NewString(),
+		Attributes: binding.NewString(),
+		Sort:       binding.NewString(),
 	}
+	res.Scope.Set(ScopeSub)
+	res.Filter.Set("(objectClass=*)")
 	return res
 }
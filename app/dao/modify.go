@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"slices"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// AddValueChanges adds the changes that turn the old values of an attribute
+// into the new ones. Removed values are deleted and new ones added, so other
+// values are left alone; the attribute is only replaced when it has a
+// single value, or when its values were reordered, which delete and add
+// cannot express.
+func AddValueChanges(req *ldap.ModifyRequest, attr string, oldValues, newValues []string) {
+	if slices.Equal(oldValues, newValues) {
+		return
+	}
+	switch {
+	case len(newValues) == 0:
+		req.Delete(attr, nil)
+		return
+	case len(oldValues) == 0:
+		req.Add(attr, newValues)
+		return
+	case len(oldValues) == 1 && len(newValues) == 1:
+		req.Replace(attr, newValues)
+		return
+	}
+
+	var deleted, added, kept []string
+	for _, v := range oldValues {
+		if slices.Contains(newValues, v) {
+			kept = append(kept, v)
+		} else {
+			deleted = append(deleted, v)
+		}
+	}
+	for _, v := range newValues {
+		if !slices.Contains(oldValues, v) {
+			added = append(added, v)
+		}
+	}
+	// 服务器按 删除, 添加 的顺序处理后得到的值
+	if !slices.Equal(append(kept, added...), newValues) {
+		req.Replace(attr, newValues)
+		return
+	}
+	if len(deleted) > 0 {
+		req.Delete(attr, deleted)
+	}
+	if len(added) > 0 {
+		req.Add(attr, added)
+	}
+}
@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestAddValueChanges(t *testing.T) {
+	type change struct {
+		op     uint
+		values []string
+	}
+	tests := []struct {
+		name     string
+		old, new []string
+		want     []change
+	}{
+		{"unchanged", []string{"a", "b"}, []string{"a", "b"}, nil},
+		{"all removed", []string{"a", "b"}, nil, []change{{ldap.DeleteAttribute, nil}}},
+		{"first values", nil, []string{"a", "b"}, []change{{ldap.AddAttribute, []string{"a", "b"}}}},
+		{"single value", []string{"a"}, []string{"b"}, []change{{ldap.ReplaceAttribute, []string{"b"}}}},
+		{"value added", []string{"a", "b"}, []string{"a", "b", "c"}, []change{{ldap.AddAttribute, []string{"c"}}}},
+		{"value deleted", []string{"a", "b", "c"}, []string{"a", "c"}, []change{{ldap.DeleteAttribute, []string{"b"}}}},
+		{"value changed", []string{"a", "b"}, []string{"a", "c"}, []change{
+			{ldap.DeleteAttribute, []string{"b"}},
+			{ldap.AddAttribute, []string{"c"}},
+		}},
+		// 删除和添加无法表达新的顺序
+		{"reordered", []string{"a", "b"}, []string{"b", "a"}, []change{{ldap.ReplaceAttribute, []string{"b", "a"}}}},
+		{"inserted before", []string{"a", "b"}, []string{"c", "a", "b"}, []change{{ldap.ReplaceAttribute, []string{"c", "a", "b"}}}},
+	}
+	for _, tt := range tests {
+		req := ldap.NewModifyRequest("cn=x", nil)
+		AddValueChanges(req, "mail", tt.old, tt.new)
+		var got []change
+		for _, c := range req.Changes {
+			if c.Modification.Type != "mail" {
+				t.Errorf("%s: change of %q, want mail", tt.name, c.Modification.Type)
+			}
+			got = append(got, change{c.Operation, c.Modification.Vals})
+		}
+		if !slices.EqualFunc(got, tt.want, func(a, b change) bool {
+			return a.op == b.op && slices.Equal(a.values, b.values)
+		}) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// RootDSE holds what the server publishes about itself in the rootDSE
+type RootDSE struct {
+	NamingContexts          []string
+	DefaultNamingContext    string // Active Directory only
+	SubschemaSubentry       string
+	SupportedLDAPVersion    []string
+	SupportedControl        []string
+	SupportedExtension      []string
+	SupportedSASLMechanisms []string
+	VendorName              string
+	VendorVersion           string
+}
+
+// ReadRootDSE reads the rootDSE of the server
+func ReadRootDSE(l *ldap.Conn) (*RootDSE, error) {
+	sr, err := l.Search(ldap.NewSearchRequest(
+		"",
+		ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=*)",
+		[]string{
+			"namingContexts", "defaultNamingContext", "subschemaSubentry",
+			"supportedLDAPVersion", "supportedControl", "supportedExtension", "supportedSASLMechanisms",
+			"vendorName", "vendorVersion",
+		},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("read rootDSE: %w", err)
+	}
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("read rootDSE: no entry returned")
+	}
+	entry := sr.Entries[0]
+	return &RootDSE{
+		NamingContexts:          entry.GetAttributeValues("namingContexts"),
+		DefaultNamingContext:    entry.GetAttributeValue("defaultNamingContext"),
+		SubschemaSubentry:       entry.GetAttributeValue("subschemaSubentry"),
+		SupportedLDAPVersion:    entry.GetAttributeValues("supportedLDAPVersion"),
+		SupportedControl:        entry.GetAttributeValues("supportedControl"),
+		SupportedExtension:      entry.GetAttributeValues("supportedExtension"),
+		SupportedSASLMechanisms: entry.GetAttributeValues("supportedSASLMechanisms"),
+		VendorName:              entry.GetAttributeValue("vendorName"),
+		VendorVersion:           entry.GetAttributeValue("vendorVersion"),
+	}, nil
+}
+
+// DefaultBaseDN returns the naming context a search should start from
+func (r *RootDSE) DefaultBaseDN() string {
+	if r.DefaultNamingContext != "" {
+		return r.DefaultNamingContext
+	}
+	if len(r.NamingContexts) > 0 {
+		return r.NamingContexts[0]
+	}
+	return ""
+}
+
+// SupportsControl reports whether the server advertises a control OID
+func (r *RootDSE) SupportsControl(oid string) bool {
+	for _, c := range r.SupportedControl {
+		if strings.TrimSpace(c) == oid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,437 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ObjectClass is an objectClasses definition from the subschema entry (RFC 4512 4.1.1)
+type ObjectClass struct {
+	OID      string
+	Names    []string
+	Desc     string
+	Obsolete bool
+	Sup      []string
+	Kind     string // ABSTRACT, STRUCTURAL or AUXILIARY
+	Must     []string
+	May      []string
+}
+
+// AttributeType is an attributeTypes definition from the subschema entry (RFC 4512 4.1.2)
+type AttributeType struct {
+	OID                string
+	Names              []string
+	Desc               string
+	Obsolete           bool
+	Sup                string
+	Equality           string
+	Ordering           string
+	Substr             string
+	Syntax             string
+	SyntaxLen          string // upper bound from "{n}", if any
+	SingleValue        bool
+	Collective         bool
+	NoUserModification bool
+	Usage              string
+}
+
+// LDAPSyntax is an ldapSyntaxes definition from the subschema entry (RFC 4512 4.1.5)
+type LDAPSyntax struct {
+	OID  string
+	Desc string
+}
+
+// MatchingRule is a matchingRules definition from the subschema entry (RFC 4512 4.1.3)
+type MatchingRule struct {
+	OID      string
+	Names    []string
+	Desc     string
+	Obsolete bool
+	Syntax   string
+}
+
+// Schema holds the parsed subschema entry of a server
+type Schema struct {
+	ObjectClasses  []*ObjectClass
+	AttributeTypes []*AttributeType
+	Syntaxes       []*LDAPSyntax
+	MatchingRules  []*MatchingRule
+
+	objectClasses  map[string]*ObjectClass   // 小写名称和OID
+	attributeTypes map[string]*AttributeType // 小写名称和OID
+	syntaxes       map[string]*LDAPSyntax
+	matchingRules  map[string]*MatchingRule
+}
+
+// Name returns the first name of the object class, or its OID
+func (o *ObjectClass) Name() string {
+	return firstName(o.Names, o.OID)
+}
+
+// Name returns the first name of the attribute type, or its OID
+func (a *AttributeType) Name() string {
+	return firstName(a.Names, a.OID)
+}
+
+// Name returns the first name of the matching rule, or its OID
+func (m *MatchingRule) Name() string {
+	return firstName(m.Names, m.OID)
+}
+
+func firstName(names []string, oid string) string {
+	if len(names) > 0 {
+		return names[0]
+	}
+	return oid
+}
+
+// ReadSchema reads and parses the subschema entry the rootDSE points to
+func ReadSchema(l *ldap.Conn) (*Schema, error) {
+	dn, err := subschemaDN(l)
+	if err != nil {
+		return nil, err
+	}
+	sr, err := l.Search(ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=subschema)",
+		[]string{"objectClasses", "attributeTypes", "ldapSyntaxes", "matchingRules"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("read schema %s: %w", dn, err)
+	}
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("schema entry %s not found", dn)
+	}
+	return ParseSchema(sr.Entries[0]), nil
+}
+
+// ParseSchema parses the definitions of a subschema entry. Definitions that
+// cannot be parsed are skipped.
+func ParseSchema(entry *ldap.Entry) *Schema {
+	s := &Schema{
+		objectClasses:  make(map[string]*ObjectClass),
+		attributeTypes: make(map[string]*AttributeType),
+		syntaxes:       make(map[string]*LDAPSyntax),
+		matchingRules:  make(map[string]*MatchingRule),
+	}
+	for _, def := range entry.GetAttributeValues("objectClasses") {
+		d, err := parseDefinition(def)
+		if err != nil {
+			continue
+		}
+		oc := &ObjectClass{
+			OID:      d.oid,
+			Names:    d.values["NAME"],
+			Desc:     d.first("DESC"),
+			Obsolete: d.flags["OBSOLETE"],
+			Sup:      d.values["SUP"],
+			Kind:     "STRUCTURAL", // RFC 4512 的默认值
+			Must:     d.values["MUST"],
+			May:      d.values["MAY"],
+		}
+		for _, kind := range []string{"ABSTRACT", "STRUCTURAL", "AUXILIARY"} {
+			if d.flags[kind] {
+				oc.Kind = kind
+			}
+		}
+		s.ObjectClasses = append(s.ObjectClasses, oc)
+		for _, key := range append([]string{oc.OID}, oc.Names...) {
+			s.objectClasses[strings.ToLower(key)] = oc
+		}
+	}
+	for _, def := range entry.GetAttributeValues("attributeTypes") {
+		d, err := parseDefinition(def)
+		if err != nil {
+			continue
+		}
+		at := &AttributeType{
+			OID:                d.oid,
+			Names:              d.values["NAME"],
+			Desc:               d.first("DESC"),
+			Obsolete:           d.flags["OBSOLETE"],
+			Sup:                d.first("SUP"),
+			Equality:           d.first("EQUALITY"),
+			Ordering:           d.first("ORDERING"),
+			Substr:             d.first("SUBSTR"),
+			Syntax:             d.first("SYNTAX"),
+			SingleValue:        d.flags["SINGLE-VALUE"],
+			Collective:         d.flags["COLLECTIVE"],
+			NoUserModification: d.flags["NO-USER-MODIFICATION"],
+			Usage:              d.first("USAGE"),
+		}
+		if i := strings.IndexByte(at.Syntax, '{'); i >= 0 {
+			at.SyntaxLen = strings.TrimSuffix(at.Syntax[i+1:], "}")
+			at.Syntax = at.Syntax[:i]
+		}
+		if at.Usage == "" {
+			at.Usage = "userApplications"
+		}
+		s.AttributeTypes = append(s.AttributeTypes, at)
+		for _, key := range append([]string{at.OID}, at.Names...) {
+			s.attributeTypes[strings.ToLower(key)] = at
+		}
+	}
+	for _, def := range entry.GetAttributeValues("ldapSyntaxes") {
+		d, err := parseDefinition(def)
+		if err != nil {
+			continue
+		}
+		syntax := &LDAPSyntax{OID: d.oid, Desc: d.first("DESC")}
+		s.Syntaxes = append(s.Syntaxes, syntax)
+		s.syntaxes[syntax.OID] = syntax
+	}
+	for _, def := range entry.GetAttributeValues("matchingRules") {
+		d, err := parseDefinition(def)
+		if err != nil {
+			continue
+		}
+		rule := &MatchingRule{
+			OID:      d.oid,
+			Names:    d.values["NAME"],
+			Desc:     d.first("DESC"),
+			Obsolete: d.flags["OBSOLETE"],
+			Syntax:   d.first("SYNTAX"),
+		}
+		s.MatchingRules = append(s.MatchingRules, rule)
+		for _, key := range append([]string{rule.OID}, rule.Names...) {
+			s.matchingRules[strings.ToLower(key)] = rule
+		}
+	}
+
+	sort.Slice(s.ObjectClasses, func(i, j int) bool {
+		return strings.ToLower(s.ObjectClasses[i].Name()) < strings.ToLower(s.ObjectClasses[j].Name())
+	})
+	sort.Slice(s.AttributeTypes, func(i, j int) bool {
+		return strings.ToLower(s.AttributeTypes[i].Name()) < strings.ToLower(s.AttributeTypes[j].Name())
+	})
+	sort.Slice(s.Syntaxes, func(i, j int) bool { return s.Syntaxes[i].Desc < s.Syntaxes[j].Desc })
+	sort.Slice(s.MatchingRules, func(i, j int) bool {
+		return strings.ToLower(s.MatchingRules[i].Name()) < strings.ToLower(s.MatchingRules[j].Name())
+	})
+	return s
+}
+
+// ObjectClass looks up an object class by name or OID, ignoring case
+func (s *Schema) ObjectClass(name string) *ObjectClass {
+	return s.objectClasses[strings.ToLower(name)]
+}
+
+// AttributeType looks up an attribute type by name or OID, ignoring case.
+// Attribute options such as ";binary" are ignored.
+func (s *Schema) AttributeType(name string) *AttributeType {
+	name = strings.SplitN(name, ";", 2)[0]
+	return s.attributeTypes[strings.ToLower(name)]
+}
+
+// Syntax looks up an LDAP syntax by OID
+func (s *Schema) Syntax(oid string) *LDAPSyntax {
+	return s.syntaxes[oid]
+}
+
+// MatchingRule looks up a matching rule by name or OID, ignoring case
+func (s *Schema) MatchingRule(name string) *MatchingRule {
+	return s.matchingRules[strings.ToLower(name)]
+}
+
+// ClassChain returns an object class followed by all of its superclasses
+func (s *Schema) ClassChain(name string) []*ObjectClass {
+	var res []*ObjectClass
+	seen := make(map[*ObjectClass]bool)
+	queue := []string{name}
+	for len(queue) > 0 {
+		oc := s.ObjectClass(queue[0])
+		queue = queue[1:]
+		if oc == nil || seen[oc] {
+			continue
+		}
+		seen[oc] = true
+		res = append(res, oc)
+		queue = append(queue, oc.Sup...)
+	}
+	return res
+}
+
+// AllowedAttributes returns the MUST and MAY attributes of a set of object
+// classes, including those inherited from superclasses. An attribute that is
+// required by one class is only returned in must.
+func (s *Schema) AllowedAttributes(classes []string) (must, may []string) {
+	mustSeen := make(map[string]bool)
+	maySeen := make(map[string]bool)
+	for _, class := range classes {
+		for _, oc := range s.ClassChain(class) {
+			for _, attr := range oc.Must {
+				if !mustSeen[strings.ToLower(attr)] {
+					mustSeen[strings.ToLower(attr)] = true
+					must = append(must, attr)
+				}
+			}
+		}
+	}
+	for _, class := range classes {
+		for _, oc := range s.ClassChain(class) {
+			for _, attr := range oc.May {
+				key := strings.ToLower(attr)
+				if !mustSeen[key] && !maySeen[key] {
+					maySeen[key] = true
+					may = append(may, attr)
+				}
+			}
+		}
+	}
+	return must, may
+}
+
+// AttributeSyntaxLen returns the upper bound of an attribute's values given
+// as {n} after its syntax, following SUP like AttributeSyntax. 0 means none.
+func (s *Schema) AttributeSyntaxLen(name string) int {
+	for i := 0; i < 10; i++ { // 防止 SUP 循环
+		at := s.AttributeType(name)
+		if at == nil {
+			return 0
+		}
+		if at.Syntax != "" || at.Sup == "" {
+			n, _ := strconv.Atoi(at.SyntaxLen)
+			return n
+		}
+		name = at.Sup
+	}
+	return 0
+}
+
+// IsInteger reports whether an attribute has the INTEGER syntax. A nil
+// schema knows no syntaxes.
+func (s *Schema) IsInteger(name string) bool {
+	return s != nil && s.AttributeSyntax(name) == integerSyntax
+}
+
+// AttributeSyntax returns the syntax OID of an attribute, following SUP
+// when the attribute type does not define one itself.
+func (s *Schema) AttributeSyntax(name string) string {
+	for i := 0; i < 10; i++ { // 防止 SUP 循环
+		at := s.AttributeType(name)
+		if at == nil {
+			return ""
+		}
+		if at.Syntax != "" || at.Sup == "" {
+			return at.Syntax
+		}
+		name = at.Sup
+	}
+	return ""
+}
+
+// definition is a parsed RFC 4512 description: an OID followed by keywords
+// with zero or more values.
+type definition struct {
+	oid    string
+	values map[string][]string
+	flags  map[string]bool
+}
+
+func (d *definition) first(keyword string) string {
+	if v := d.values[keyword]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// parseDefinition parses "( oid KEYWORD value KEYWORD ( value $ value ) FLAG ... )"
+func parseDefinition(def string) (*definition, error) {
+	tokens, err := tokenizeDefinition(def)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 3 || tokens[0] != "(" || tokens[len(tokens)-1] != ")" {
+		return nil, fmt.Errorf("invalid schema definition %q", def)
+	}
+	tokens = tokens[1 : len(tokens)-1]
+	d := &definition{
+		oid:    tokens[0],
+		values: make(map[string][]string),
+		flags:  make(map[string]bool),
+	}
+	for i := 1; i < len(tokens); i++ {
+		keyword := tokens[i]
+		if i+1 >= len(tokens) || isKeyword(tokens[i+1]) {
+			d.flags[keyword] = true
+			continue
+		}
+		i++
+		if tokens[i] != "(" {
+			d.values[keyword] = []string{unquote(tokens[i])}
+			continue
+		}
+		for i++; i < len(tokens) && tokens[i] != ")"; i++ {
+			if tokens[i] != "$" {
+				d.values[keyword] = append(d.values[keyword], unquote(tokens[i]))
+			}
+		}
+	}
+	return d, nil
+}
+
+// isKeyword reports whether a token starts a new field, such as NAME or X-ORIGIN
+func isKeyword(token string) bool {
+	if token == "" || token[0] == '\'' || token == "(" || token == ")" {
+		return false
+	}
+	for _, c := range token {
+		if !(c >= 'A' && c <= 'Z' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func unquote(token string) string {
+	if len(token) >= 2 && token[0] == '\'' && token[len(token)-1] == '\'' {
+		return token[1 : len(token)-1]
+	}
+	return token
+}
+
+// tokenizeDefinition splits a definition into parentheses, quoted strings and words
+func tokenizeDefinition(def string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(def); {
+		switch c := def[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'':
+			end := strings.IndexByte(def[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in schema definition %q", def)
+			}
+			tokens = append(tokens, def[i:i+end+2])
+			i += end + 2
+		default:
+			start := i
+			for i < len(def) && !strings.ContainsRune(" \t\n\r()'", rune(def[i])) {
+				i++
+			}
+			tokens = append(tokens, def[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// subschemaDN finds the subschema entry from the rootDSE
+func subschemaDN(l *ldap.Conn) (string, error) {
+	rootDSE, err := ReadRootDSE(l)
+	if err != nil {
+		return "", err
+	}
+	if rootDSE.SubschemaSubentry == "" {
+		return "", fmt.Errorf("server does not publish subschemaSubentry")
+	}
+	return rootDSE.SubschemaSubentry, nil
+}
@@ -0,0 +1,157 @@
+package dao
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func testSchema() *Schema {
+	return ParseSchema(ldap.NewEntry("cn=Subschema", map[string][]string{
+		"attributeTypes": {
+			"( 2.5.4.41 NAME 'name' EQUALITY caseIgnoreMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15{32768} )",
+			"( 2.5.4.3 NAME ( 'cn' 'commonName' ) DESC 'common name' SUP name )",
+			"( 0.9.2342.19200300.100.1.1 NAME ( 'uid' 'userid' ) EQUALITY caseIgnoreMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15{5} )",
+			"( 1.3.6.1.4.1.9999.1 NAME 'shortUid' SUP uid )",
+			"( 1.3.6.1.1.1.1.0 NAME 'uidNumber' EQUALITY integerMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.27 SINGLE-VALUE )",
+			"( 2.5.18.1 NAME 'createTimestamp' SYNTAX 1.3.6.1.4.1.1466.115.121.1.24 SINGLE-VALUE NO-USER-MODIFICATION USAGE directoryOperation )",
+			"( 1.3.6.1.4.1.9999.2 NAME 'loopA' SUP loopB )",
+			"( 1.3.6.1.4.1.9999.3 NAME 'loopB' SUP loopA )",
+			"( broken",
+		},
+		"objectClasses": {
+			"( 2.5.6.0 NAME 'top' ABSTRACT MUST objectClass )",
+			"( 2.5.6.6 NAME 'person' SUP top STRUCTURAL MUST ( sn $ cn ) MAY ( userPassword $ telephoneNumber ) )",
+			"( 2.5.6.7 NAME 'organizationalPerson' SUP person MAY ( title $ ou ) )",
+		},
+	}))
+}
+
+func TestParseSchemaAttributeTypes(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		name      string
+		oid       string
+		names     []string
+		sup       string
+		syntax    string
+		syntaxLen string
+	}{
+		{"cn", "2.5.4.3", []string{"cn", "commonName"}, "name", "", ""},
+		{"COMMONNAME", "2.5.4.3", []string{"cn", "commonName"}, "name", "", ""},
+		{"2.5.4.3", "2.5.4.3", []string{"cn", "commonName"}, "name", "", ""},
+		{"userid", "0.9.2342.19200300.100.1.1", []string{"uid", "userid"}, "", "1.3.6.1.4.1.1466.115.121.1.15", "5"},
+		{"name", "2.5.4.41", []string{"name"}, "", "1.3.6.1.4.1.1466.115.121.1.15", "32768"},
+		{"uidNumber", "1.3.6.1.1.1.1.0", []string{"uidNumber"}, "", "1.3.6.1.4.1.1466.115.121.1.27", ""},
+	}
+	for _, tt := range tests {
+		at := s.AttributeType(tt.name)
+		if at == nil {
+			t.Errorf("AttributeType(%q) not found", tt.name)
+			continue
+		}
+		if at.OID != tt.oid || !slices.Equal(at.Names, tt.names) || at.Sup != tt.sup ||
+			at.Syntax != tt.syntax || at.SyntaxLen != tt.syntaxLen {
+			t.Errorf("AttributeType(%q) = %+v", tt.name, at)
+		}
+	}
+
+	ts := s.AttributeType("createTimestamp")
+	if ts == nil || !ts.SingleValue || !ts.NoUserModification || ts.Usage != "directoryOperation" {
+		t.Errorf("createTimestamp = %+v", ts)
+	}
+	if at := s.AttributeType("cn"); at.Usage != "userApplications" || at.Desc != "common name" {
+		t.Errorf("cn = %+v", at)
+	}
+	if len(s.AttributeTypes) != 8 {
+		t.Errorf("got %d attribute types, want 8 without the broken one", len(s.AttributeTypes))
+	}
+}
+
+func TestSchemaFollowsSup(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		name      string
+		syntax    string
+		syntaxLen int
+		integer   bool
+	}{
+		{"cn", "1.3.6.1.4.1.1466.115.121.1.15", 32768, false},
+		{"uid", "1.3.6.1.4.1.1466.115.121.1.15", 5, false},
+		{"shortUid", "1.3.6.1.4.1.1466.115.121.1.15", 5, false},
+		{"uidNumber", "1.3.6.1.4.1.1466.115.121.1.27", 0, true},
+		{"unknown", "", 0, false},
+		{"loopA", "", 0, false},
+	}
+	for _, tt := range tests {
+		if got := s.AttributeSyntax(tt.name); got != tt.syntax {
+			t.Errorf("AttributeSyntax(%q) = %q, want %q", tt.name, got, tt.syntax)
+		}
+		if got := s.AttributeSyntaxLen(tt.name); got != tt.syntaxLen {
+			t.Errorf("AttributeSyntaxLen(%q) = %d, want %d", tt.name, got, tt.syntaxLen)
+		}
+		if got := s.IsInteger(tt.name); got != tt.integer {
+			t.Errorf("IsInteger(%q) = %v, want %v", tt.name, got, tt.integer)
+		}
+	}
+	var nilSchema *Schema
+	if nilSchema.IsInteger("uidNumber") {
+		t.Error("a nil schema knows no INTEGER attributes")
+	}
+}
+
+func TestParseSchemaObjectClasses(t *testing.T) {
+	s := testSchema()
+	person := s.ObjectClass("PERSON")
+	if person == nil {
+		t.Fatal("person not found")
+	}
+	if person.Kind != "STRUCTURAL" || !slices.Equal(person.Sup, []string{"top"}) ||
+		!slices.Equal(person.Must, []string{"sn", "cn"}) ||
+		!slices.Equal(person.May, []string{"userPassword", "telephoneNumber"}) {
+		t.Errorf("person = %+v", person)
+	}
+	if top := s.ObjectClass("top"); top == nil || top.Kind != "ABSTRACT" {
+		t.Errorf("top = %+v", top)
+	}
+	// 没有写明类型时默认为 STRUCTURAL
+	if op := s.ObjectClass("organizationalPerson"); op == nil || op.Kind != "STRUCTURAL" {
+		t.Errorf("organizationalPerson = %+v", op)
+	}
+
+	must, may := s.AllowedAttributes([]string{"organizationalPerson"})
+	if !slices.Equal(must, []string{"sn", "cn", "objectClass"}) {
+		t.Errorf("must = %q", must)
+	}
+	for _, attr := range []string{"userPassword", "telephoneNumber", "title", "ou"} {
+		if !slices.Contains(may, attr) {
+			t.Errorf("may = %q, missing %s", may, attr)
+		}
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		attr   string
+		values []string
+		ok     bool
+	}{
+		{"uid", []string{"zhang"}, true},
+		{"uid", []string{"zhangw"}, false},
+		{"shortUid", []string{"abcdefghij"}, false},
+		{"uid", []string{"张伟伟伟伟"}, true}, // 长度按字符计
+		{"uidNumber", []string{"1001"}, true},
+		{"uidNumber", []string{"10a"}, false},
+		{"uidNumber", []string{"1", "2"}, false},
+		{"createTimestamp", []string{"20240513083000Z"}, false},
+		{"unknown", []string{"x"}, false},
+	}
+	for _, tt := range tests {
+		err := s.ValidateValues(tt.attr, tt.values)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateValues(%q, %q) = %v, want ok %v", tt.attr, tt.values, err, tt.ok)
+		}
+	}
+}
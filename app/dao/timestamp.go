@@ -0,0 +1,132 @@
+package dao
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeKind tells how an attribute encodes a point in time
+type TimeKind int
+
+const (
+	NotTime         TimeKind = iota
+	GeneralizedTime          // 20240513083000Z
+	FileTime                 // Windows FILETIME, 100ns 间隔数, 从 1601 年开始
+	DayCount                 // POSIX 天数, 从 1970 年开始
+)
+
+const generalizedTimeSyntax = "1.3.6.1.4.1.1466.115.121.1.24"
+
+// generalizedTimeValue matches RFC 4517 generalizedTime: minutes and seconds
+// may be left out, the last unit may have a fraction, the zone is required
+var generalizedTimeValue = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})(\d{2})(?:(\d{2})(\d{2})?)?(?:[.,](\d+))?(Z|[+-]\d{2}(?:\d{2})?)$`)
+
+// fileTimeEpoch is 1601-01-01 in 100ns intervals before the Unix epoch
+const fileTimeEpoch = 116444736000000000
+
+var timeAttributes = map[string]TimeKind{
+	"createtimestamp":      GeneralizedTime,
+	"modifytimestamp":      GeneralizedTime,
+	"pwdchangedtime":       GeneralizedTime,
+	"pwdaccountlockedtime": GeneralizedTime,
+	"whencreated":          GeneralizedTime,
+	"whenchanged":          GeneralizedTime,
+	"lastlogontimestamp":   FileTime,
+	"lastlogon":            FileTime,
+	"lastlogoff":           FileTime,
+	"pwdlastset":           FileTime,
+	"accountexpires":       FileTime,
+	"badpasswordtime":      FileTime,
+	"lockouttime":          FileTime,
+	"shadowlastchange":     DayCount,
+	"shadowexpire":         DayCount,
+}
+
+// TimeKindOf tells whether an attribute holds a time, judging by its name
+// and its syntax in the schema, which may be nil.
+func TimeKindOf(s *Schema, attr string) TimeKind {
+	name, _, _ := strings.Cut(attr, ";")
+	if kind, ok := timeAttributes[strings.ToLower(name)]; ok {
+		return kind
+	}
+	if s != nil {
+		if s.AttributeSyntax(name) == generalizedTimeSyntax {
+			return GeneralizedTime
+		}
+	}
+	return NotTime
+}
+
+// ParseTime decodes a time value. The zero time is returned for values
+// meaning never, such as 0 or the largest FILETIME in accountExpires.
+func ParseTime(kind TimeKind, value string) (time.Time, error) {
+	switch kind {
+	case GeneralizedTime:
+		return parseGeneralizedTime(value)
+	case FileTime:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse FILETIME %q: %w", value, err)
+		}
+		if v <= 0 || v == math.MaxInt64 {
+			return time.Time{}, nil
+		}
+		v -= fileTimeEpoch
+		return time.Unix(v/1e7, v%1e7*100), nil
+	case DayCount:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse day count %q: %w", value, err)
+		}
+		if v < 0 {
+			return time.Time{}, nil
+		}
+		return time.Unix(v*24*60*60, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time", value)
+}
+
+// parseGeneralizedTime decodes a generalizedTime value such as
+// 20240513083000Z, 202405130830Z, 2024051308.5+0200 or 20240513083000.123Z
+func parseGeneralizedTime(value string) (time.Time, error) {
+	m := generalizedTimeValue.FindStringSubmatch(value)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("parse generalized time %q: invalid format", value)
+	}
+	num := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	year, month, day, hour, minute, second := num(m[1]), num(m[2]), num(m[3]), num(m[4]), num(m[5]), num(m[6])
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || second > 60 {
+		return time.Time{}, fmt.Errorf("parse generalized time %q: value out of range", value)
+	}
+
+	loc := time.UTC
+	if zone := m[8]; zone != "Z" {
+		offset := num(zone[1:3])*60*60 + num(zone[3:])*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone(zone, offset)
+	}
+	t := time.Date(year, time.Month(month), day, hour, minute, second, 0, loc)
+
+	// 小数部分属于最后给出的单位
+	if m[7] != "" {
+		fraction, _ := strconv.ParseFloat("0."+m[7], 64)
+		unit := time.Second
+		switch {
+		case m[5] == "":
+			unit = time.Hour
+		case m[6] == "":
+			unit = time.Minute
+		}
+		t = t.Add(time.Duration(fraction * float64(unit)))
+	}
+	return t, nil
+}
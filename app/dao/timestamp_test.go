@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	east8 := time.FixedZone("+0800", 8*60*60)
+	tests := []struct {
+		kind  TimeKind
+		value string
+		want  time.Time
+		fails bool
+	}{
+		{kind: GeneralizedTime, value: "20240513083000Z", want: time.Date(2024, 5, 13, 8, 30, 0, 0, time.UTC)},
+		{kind: GeneralizedTime, value: "202405130830Z", want: time.Date(2024, 5, 13, 8, 30, 0, 0, time.UTC)},
+		{kind: GeneralizedTime, value: "2024051308Z", want: time.Date(2024, 5, 13, 8, 0, 0, 0, time.UTC)},
+		{kind: GeneralizedTime, value: "20240513083000.123Z", want: time.Date(2024, 5, 13, 8, 30, 0, 123e6, time.UTC)},
+		{kind: GeneralizedTime, value: "20240513083000,5Z", want: time.Date(2024, 5, 13, 8, 30, 0, 500e6, time.UTC)},
+		// 小数属于最后给出的单位
+		{kind: GeneralizedTime, value: "2024051308.5Z", want: time.Date(2024, 5, 13, 8, 30, 0, 0, time.UTC)},
+		{kind: GeneralizedTime, value: "202405130830.5Z", want: time.Date(2024, 5, 13, 8, 30, 30, 0, time.UTC)},
+		{kind: GeneralizedTime, value: "20240513163000+0800", want: time.Date(2024, 5, 13, 16, 30, 0, 0, east8)},
+		{kind: GeneralizedTime, value: "20240513163000+08", want: time.Date(2024, 5, 13, 16, 30, 0, 0, east8)},
+		{kind: GeneralizedTime, value: "20240513003000-0800", want: time.Date(2024, 5, 13, 8, 30, 0, 0, time.UTC)},
+		{kind: GeneralizedTime, value: "20240513083000", fails: true},
+		{kind: GeneralizedTime, value: "20241313083000Z", fails: true},
+		{kind: GeneralizedTime, value: "20240513250000Z", fails: true},
+		{kind: GeneralizedTime, value: "2024-05-13", fails: true},
+
+		{kind: FileTime, value: "133600626000000000", want: time.Date(2024, 5, 13, 8, 30, 0, 0, time.UTC)},
+		{kind: FileTime, value: "0"},
+		{kind: FileTime, value: "9223372036854775807"},
+		{kind: FileTime, value: "never", fails: true},
+
+		{kind: DayCount, value: "19856", want: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{kind: DayCount, value: "-1"},
+		{kind: DayCount, value: "1.5", fails: true},
+
+		{kind: NotTime, value: "20240513083000Z", fails: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.kind, tt.value)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ParseTime(%d, %q) = %v, want an error", tt.kind, tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTime(%d, %q): %v", tt.kind, tt.value, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%d, %q) = %v, want %v", tt.kind, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTimeKindOf(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		attr   string
+		schema *Schema
+		want   TimeKind
+	}{
+		{"modifyTimestamp", nil, GeneralizedTime},
+		{"pwdLastSet", nil, FileTime},
+		{"shadowLastChange;x-opt", nil, DayCount},
+		{"createTimestamp", s, GeneralizedTime},
+		{"cn", s, NotTime},
+		{"cn", nil, NotTime},
+	}
+	for _, tt := range tests {
+		if got := TimeKindOf(tt.schema, tt.attr); got != tt.want {
+			t.Errorf("TimeKindOf(%q) = %d, want %d", tt.attr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package dao
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// RFC 4517 syntaxes whose values are checked before they are sent
+const (
+	booleanSyntax   = "1.3.6.1.4.1.1466.115.121.1.7"
+	ia5StringSyntax = "1.3.6.1.4.1.1466.115.121.1.26"
+	integerSyntax   = "1.3.6.1.4.1.1466.115.121.1.27"
+	numericSyntax   = "1.3.6.1.4.1.1466.115.121.1.36"
+	printableSyntax = "1.3.6.1.4.1.1466.115.121.1.44"
+	dnSyntax        = "1.3.6.1.4.1.1466.115.121.1.12"
+)
+
+// Syntaxes of attributes holding DNs, used to link entries
+const (
+	DNSyntax         = dnSyntax
+	NameAndUIDSyntax = "1.3.6.1.4.1.1466.115.121.1.34"
+)
+
+var (
+	integerValue   = regexp.MustCompile(`^-?[0-9]+$`)
+	numericValue   = regexp.MustCompile(`^[0-9 ]+$`)
+	printableValue = regexp.MustCompile(`^[A-Za-z0-9'()+,\-./:?= ]+$`)
+)
+
+// ValidateValues checks the values of an attribute against the schema: that
+// the attribute is known and not operational, that a single-valued attribute
+// has one value, the length bound and the syntax of common types. Syntaxes
+// it does not know are not checked.
+func (s *Schema) ValidateValues(attr string, values []string) error {
+	at := s.AttributeType(attr)
+	if at == nil {
+		return fmt.Errorf("%s is not defined in the schema", attr)
+	}
+	if at.NoUserModification {
+		return fmt.Errorf("%s is set by the server", attr)
+	}
+	if at.SingleValue && len(values) > 1 {
+		return fmt.Errorf("%s takes a single value, got %d", attr, len(values))
+	}
+	syntax := s.AttributeSyntax(attr)
+	limit := s.AttributeSyntaxLen(attr)
+	for _, v := range values {
+		if limit > 0 && utf8.RuneCountInString(v) > limit {
+			return fmt.Errorf("%s: %q is longer than %d characters", attr, v, limit)
+		}
+		if err := validateSyntax(syntax, v); err != nil {
+			return fmt.Errorf("%s: %q %w", attr, v, err)
+		}
+	}
+	return nil
+}
+
+func validateSyntax(syntax, value string) error {
+	switch syntax {
+	case booleanSyntax:
+		if value != "TRUE" && value != "FALSE" {
+			return fmt.Errorf("must be TRUE or FALSE")
+		}
+	case integerSyntax:
+		if !integerValue.MatchString(value) {
+			return fmt.Errorf("is not an integer")
+		}
+	case numericSyntax:
+		if !numericValue.MatchString(value) {
+			return fmt.Errorf("may only have digits and spaces")
+		}
+	case printableSyntax:
+		if !printableValue.MatchString(value) {
+			return fmt.Errorf("has characters a printable string cannot have")
+		}
+	case ia5StringSyntax:
+		for _, r := range value {
+			if r > 0x7f {
+				return fmt.Errorf("may only have ASCII characters")
+			}
+		}
+	case dnSyntax:
+		if _, err := ldap.ParseDN(value); err != nil {
+			return fmt.Errorf("is not a DN: %w", err)
+		}
+	case generalizedTimeSyntax:
+		if _, err := ParseTime(GeneralizedTime, value); err != nil {
+			return fmt.Errorf("is not a generalized time: %w", err)
+		}
+	}
+	return nil
+}
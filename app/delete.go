@@ -0,0 +1,224 @@
+package app
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// supportsTreeDelete tells whether the server of a profile deletes subtrees
+// itself. Only the root DSE of the current profile is known.
+func (x *LdapAdmin) supportsTreeDelete(source string) bool {
+	x.Lock()
+	defer x.Unlock()
+	return source == "" && x.rootDSE != nil && x.rootDSE.SupportsControl(dao.TreeDeleteOID)
+}
+
+// deleteEntryShow asks before deleting an entry, showing its DN and whether
+// entries are below it, which are only deleted when the user says so. The
+// server is asked in the background first. onDeleted gets the DNs that were
+// deleted; entries below a deleted DN are gone too.
+func (x *LdapAdmin) deleteEntryShow(dn, source string, parent fyne.Window, onDeleted func(deleted []string)) {
+	go func() {
+		conn, release, err := x.connFor(source)
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		children, exact, err := dao.ChildCount(conn, dn)
+		release()
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		x.confirmDelete(dn, source, children, exact, parent, onDeleted)
+	}()
+}
+
+// confirmDelete shows the delete confirmation once the children are counted
+func (x *LdapAdmin) confirmDelete(dn, source string, children int, exact bool, parent fyne.Window, onDeleted func(deleted []string)) {
+	treeDelete := x.supportsTreeDelete(source)
+
+	dnLabel := widget.NewLabelWithStyle(dn, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	dnLabel.Wrapping = fyne.TextWrapBreak
+	content := container.NewVBox(widget.NewLabel("Delete this entry? This cannot be undone."), dnLabel)
+	below := "the entries below it"
+	if exact {
+		below = fmt.Sprintf("the %s directly below it and their children", entryCount(children))
+	}
+	var del *widget.Button
+	// 有子条目时, 只有选择一起删除才能删除
+	withSubtree := widget.NewCheck("Also delete "+below, func(checked bool) {
+		if checked {
+			del.Enable()
+		} else {
+			del.Disable()
+		}
+	})
+	stopOnError := widget.NewCheck("Stop at the first error", nil)
+	stopOnError.SetChecked(true)
+	if children == 0 {
+		content.Add(widget.NewLabel("It has no entries below it."))
+	} else {
+		count := widget.NewLabel("It has entries below it.")
+		if exact {
+			count.SetText(fmt.Sprintf("It has %s directly below it.", entryCount(children)))
+		}
+		count.Importance = widget.WarningImportance
+		content.Add(count)
+		content.Add(withSubtree)
+		if treeDelete {
+			content.Add(widget.NewLabel("The server deletes the subtree with the Tree Delete control."))
+		} else {
+			content.Add(widget.NewLabel("The server has no Tree Delete control, entries are deleted one by one, deepest first."))
+			content.Add(stopOnError)
+		}
+	}
+
+	var d dialog.Dialog
+	del = widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		d.Hide()
+		go func() {
+			var deleted []string
+			switch {
+			case children == 0:
+				deleted = x.deleteOne(dn, source, parent, func(conn *ldap.Conn) error { return dao.Delete(conn, dn) })
+			case treeDelete:
+				deleted = x.deleteOne(dn, source, parent, func(conn *ldap.Conn) error { return dao.DeleteTree(conn, dn) })
+			default:
+				// 只有逐个删除时才需要列出整个子树
+				conn, release, err := x.connFor(source)
+				if err != nil {
+					dialog.ShowError(err, parent)
+					return
+				}
+				subtree, err := dao.ListSubtree(conn, dn)
+				release()
+				if err != nil {
+					dialog.ShowError(err, parent)
+					return
+				}
+				deleted = x.deleteLeafFirst(subtree, source, stopOnError.Checked, parent)
+			}
+			if len(deleted) > 0 {
+				if source == "" {
+					x.reloadTreeNode(dao.ParentDN(dn))
+				}
+				onDeleted(deleted)
+			}
+		}()
+	})
+	del.Importance = widget.DangerImportance
+	if children > 0 {
+		del.Disable()
+	}
+	d = dialog.NewCustomWithoutButtons("Delete Entry", container.NewBorder(nil,
+		container.NewHBox(layout.NewSpacer(), widget.NewButton("Cancel", func() { d.Hide() }), del), nil, nil, content), parent)
+	d.Show()
+}
+
+func entryCount(n int) string {
+	if n == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", n)
+}
+
+// deleteOne runs a single delete request, returning the DN when it worked
+func (x *LdapAdmin) deleteOne(dn, source string, parent fyne.Window, del func(conn *ldap.Conn) error) []string {
+	wait := dialog.NewCustomWithoutButtons("Deleting", widget.NewProgressBarInfinite(), parent)
+	wait.Show()
+	defer wait.Hide()
+	conn, release, err := x.connFor(source)
+	if err == nil {
+		err = del(conn)
+		release()
+	}
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return nil
+	}
+	return []string{dn}
+}
+
+// deleteLeafFirst deletes a subtree one entry at a time, dns lists the
+// deepest entries first. It can be stopped, and stops by itself on an error
+// when stopOnError is set, as the entries above a failed one cannot be deleted.
+func (x *LdapAdmin) deleteLeafFirst(dns []string, source string, stopOnError bool, parent fyne.Window) (deleted []string) {
+	var stopped atomic.Bool
+	progress := widget.NewProgressBar()
+	progress.Max = float64(len(dns))
+	current := widget.NewLabel("")
+	current.Truncation = fyne.TextTruncateEllipsis
+	wait := dialog.NewCustomWithoutButtons("Delete Subtree",
+		container.NewVBox(progress, current, widget.NewButton("Stop", func() { stopped.Store(true) })), parent)
+	wait.Resize(fyne.NewSize(500, 0))
+	wait.Show()
+
+	conn, release, err := x.connFor(source)
+	if err != nil {
+		wait.Hide()
+		dialog.ShowError(err, parent)
+		return nil
+	}
+	results := make([]batchResult, 0, len(dns))
+	for i, dn := range dns {
+		if stopped.Load() {
+			break
+		}
+		current.SetText(dn)
+		err := dao.Delete(conn, dn)
+		results = append(results, batchResult{dn: dn, err: err})
+		progress.SetValue(float64(i + 1))
+		if err == nil {
+			deleted = append(deleted, dn)
+		} else if stopOnError {
+			break
+		}
+	}
+	release()
+	wait.Hide()
+
+	title := "Delete Subtree"
+	if len(results) < len(dns) {
+		title = fmt.Sprintf("Delete Subtree (stopped, %d not tried)", len(dns)-len(results))
+	}
+	showBatchReport(title, results, parent)
+	return deleted
+}
+
+// deleteTreeSelection deletes the entry selected in the directory tree
+func (x *LdapAdmin) deleteTreeSelection() {
+	dn := x.treeSelected
+	if dn == "" {
+		return
+	}
+	x.deleteEntryShow(dn, "", x.windows, func([]string) {
+		x.treeSelected = ""
+		x.dirTree.UnselectAll()
+	})
+}
+
+// removeDeleted drops the entries with the deleted DNs, and those below
+// them, from the results
+func (t *resultTab) removeDeleted(dns []string) {
+	removed := make(map[*ldap.Entry]bool)
+	t.mu.Lock()
+	for _, entry := range t.data {
+		for _, dn := range dns {
+			if dao.IsBelow(entry.DN, dn) {
+				removed[entry] = true
+			}
+		}
+	}
+	t.mu.Unlock()
+	t.removeEntries(removed)
+}
@@ -0,0 +1,293 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
+)
+
+// detailCategory groups attributes in the detail view
+type detailCategory struct {
+	name  string
+	attrs []string
+}
+
+// detailCategories are shown in this order, followed by otherAttributes
+var detailCategories = []detailCategory{
+	{"Name Attributes", []string{"cn", "sn", "givenName", "displayName"}},
+	{"Contact Info", []string{"mail", "telephoneNumber", "mobile"}},
+	{"Account Details", []string{"uid", "uidNumber", "gidNumber", "homeDirectory", "loginShell"}},
+	{"Organization", []string{"o", "ou", "title", "manager"}},
+	{"System Attributes", []string{"objectClass", "createTimestamp", "modifyTimestamp", "creatorsName", "modifiersName"}},
+}
+
+const otherAttributes = "Other Attributes"
+
+// detailValueLimit is how many values of an attribute are shown before the
+// rest is hidden behind a button
+const detailValueLimit = 50
+
+// nameAndUIDDivider starts the optional bit string after the DN of a
+// Name and Optional UID value
+const nameAndUIDDivider = "#'"
+
+// dnAttributes hold DNs on servers that do not publish their schema
+var dnAttributes = []string{
+	"manager", "member", "uniqueMember", "seeAlso", "owner", "secretary", "roleOccupant",
+	"memberOf", "creatorsName", "modifiersName", "aliasedObjectName",
+}
+
+func (t *resultTab) createDetailView() fyne.CanvasObject {
+	t.detailContent = container.NewVBox()
+	t.refreshDetailView()
+
+	compareButton := widget.NewButton("Compare...", func() {
+		if entry := t.detailEntry(); entry != nil {
+			t.showCompareDialog(entry)
+		}
+	})
+
+	// Wrap in a scroll container and card
+	scroll := container.NewVScroll(t.detailContent)
+	return widget.NewCard("Details", "", container.NewBorder(container.NewHBox(compareButton), nil, nil, nil, scroll))
+}
+
+func (t *resultTab) refreshDetailView() {
+	if t.detailContent == nil {
+		return
+	}
+	// 被替换的值不会再收到 MouseOut
+	if t.x.resultWindow != nil {
+		if tips, ok := t.x.resultWindow.Canvas().Content().(*ext.ToolTipContainer); ok {
+			tips.HideToolTip()
+		}
+	}
+	entry := t.detailEntry()
+	if entry == nil {
+		t.detailContent.Objects = []fyne.CanvasObject{widget.NewLabel("Select an entry to view details")}
+		t.detailContent.Refresh()
+		return
+	}
+
+	header := container.New(layout.NewFormLayout(),
+		attributeName("DN"), t.detailValue(entry, "", entry.DN),
+	)
+	if source := t.sourceOf(entry); source != "" {
+		header.Add(attributeName("Source"))
+		header.Add(widget.NewLabel(source))
+	}
+	objects := []fyne.CanvasObject{header}
+
+	for _, group := range groupAttributes(entry) {
+		rows := container.New(layout.NewFormLayout())
+		for _, attr := range group.attrs {
+			rows.Add(attributeName(attr.Name))
+			rows.Add(t.detailValues(entry, attr))
+		}
+		objects = append(objects, t.detailSection(group.name, len(group.attrs), rows))
+	}
+
+	t.detailContent.Objects = objects
+	t.detailContent.Refresh()
+}
+
+// attributeGroup is one section of the detail view
+type attributeGroup struct {
+	name  string
+	attrs []*ldap.EntryAttribute
+}
+
+// groupAttributes sorts the attributes of an entry into detailCategories,
+// attributes of no category go to otherAttributes in the order of the entry.
+func groupAttributes(entry *ldap.Entry) []attributeGroup {
+	byName := make(map[string]*ldap.EntryAttribute, len(entry.Attributes))
+	for _, attr := range entry.Attributes {
+		byName[strings.ToLower(attr.Name)] = attr
+	}
+
+	var groups []attributeGroup
+	used := make(map[*ldap.EntryAttribute]bool)
+	for _, category := range detailCategories {
+		group := attributeGroup{name: category.name}
+		for _, name := range category.attrs {
+			if attr, ok := byName[strings.ToLower(name)]; ok && len(attr.Values) > 0 {
+				group.attrs = append(group.attrs, attr)
+				used[attr] = true
+			}
+		}
+		if len(group.attrs) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	others := attributeGroup{name: otherAttributes}
+	for _, attr := range entry.Attributes {
+		if !used[attr] {
+			others.attrs = append(others.attrs, attr)
+		}
+	}
+	if len(others.attrs) > 0 {
+		groups = append(groups, others)
+	}
+	return groups
+}
+
+// detailSection is a titled section that collapses when its title is
+// clicked. The state is kept by name, so it survives switching entries.
+func (t *resultTab) detailSection(name string, count int, body fyne.CanvasObject) fyne.CanvasObject {
+	if t.x.detailClosed == nil {
+		t.x.detailClosed = make(map[string]bool)
+	}
+	header := widget.NewButton(fmt.Sprintf("%s (%d)", name, count), nil)
+	header.Alignment = widget.ButtonAlignLeading
+	header.Importance = widget.LowImportance
+	update := func() {
+		if t.x.detailClosed[name] {
+			header.SetIcon(theme.MenuExpandIcon())
+			body.Hide()
+		} else {
+			header.SetIcon(theme.MenuDropDownIcon())
+			body.Show()
+		}
+	}
+	header.OnTapped = func() {
+		t.x.detailClosed[name] = !t.x.detailClosed[name]
+		update()
+	}
+	update()
+	return container.NewVBox(widget.NewSeparator(), header, body)
+}
+
+func attributeName(name string) fyne.CanvasObject {
+	return widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+}
+
+// detailValues lists the values of an attribute, long lists are cut at
+// detailValueLimit until the user asks for all of them.
+func (t *resultTab) detailValues(entry *ldap.Entry, attr *ldap.EntryAttribute) fyne.CanvasObject {
+	schema := t.x.currentSchema()
+	kind := dao.BinaryKindOf(schema, attr.Name)
+	timeKind := dao.TimeKindOf(schema, attr.Name)
+	box := container.NewVBox()
+	show := func(n int) {
+		box.Objects = nil
+		for i := 0; i < n; i++ {
+			if kind != dao.NotBinary && i < len(attr.ByteValues) {
+				box.Add(t.binaryValue(attr.Name, kind, attr.ByteValues[i]))
+				continue
+			}
+			if timeKind != dao.NotTime {
+				if obj, ok := t.timeValue(timeKind, attr.Values[i]); ok {
+					box.Add(obj)
+					continue
+				}
+			}
+			box.Add(t.detailValue(entry, attr.Name, attr.Values[i]))
+		}
+	}
+	if len(attr.Values) <= detailValueLimit {
+		show(len(attr.Values))
+		return box
+	}
+	show(detailValueLimit)
+	more := widget.NewButton(fmt.Sprintf("Show all %d values", len(attr.Values)), nil)
+	more.OnTapped = func() {
+		show(len(attr.Values))
+		box.Refresh()
+	}
+	box.Add(more)
+	return box
+}
+
+// detailValue shows one value with a copy button. DN values are links that
+// open the entry they point to.
+func (t *resultTab) detailValue(entry *ldap.Entry, attr, value string) fyne.CanvasObject {
+	var text fyne.CanvasObject
+	if dn, ok := t.x.dnValue(attr, value); ok {
+		link := widget.NewHyperlink(value, nil)
+		link.Wrapping = fyne.TextWrapBreak
+		link.OnTapped = func() { t.openLinkedEntry(entry, dn) }
+		text = link
+	} else {
+		label := widget.NewLabel(value)
+		label.Wrapping = fyne.TextWrapBreak
+		text = label
+	}
+	return t.valueRow(text, value)
+}
+
+// valueRow puts a copy button next to a value
+func (t *resultTab) valueRow(content fyne.CanvasObject, copyText string) fyne.CanvasObject {
+	copyButton := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		t.x.resultWindow.Clipboard().SetContent(copyText)
+	})
+	copyButton.Importance = widget.LowImportance
+	return container.NewBorder(nil, nil, nil, copyButton, content)
+}
+
+// dnValue returns the DN in an attribute value, if the attribute holds DNs
+// according to the schema or, without a schema, to dnAttributes.
+func (x *LdapAdmin) dnValue(attr, value string) (string, bool) {
+	if attr == "" {
+		return "", false // 条目自身的DN
+	}
+	syntax := ""
+	if schema := x.currentSchema(); schema != nil {
+		syntax = schema.AttributeSyntax(attr)
+	}
+	switch {
+	case syntax == dao.DNSyntax:
+	case syntax == dao.NameAndUIDSyntax || (syntax == "" && strings.EqualFold(attr, "uniqueMember")):
+		// 去掉可选的 #'0101'B 后缀
+		if i := strings.LastIndex(value, nameAndUIDDivider); i > 0 {
+			value = value[:i]
+		}
+	case syntax == "" && containsFold(dnAttributes, attr):
+	default:
+		return "", false
+	}
+	if value == "" {
+		return "", false
+	}
+	if _, err := ldap.ParseDN(value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// openLinkedEntry opens the entry a DN value points to, reading it in the
+// background from the server the linking entry came from.
+func (t *resultTab) openLinkedEntry(from *ldap.Entry, dn string) {
+	source := t.sourceOf(from)
+	go func() {
+		conn, release, err := t.x.connFor(source)
+		if err != nil {
+			dialog.ShowError(err, t.x.resultWindow)
+			return
+		}
+		entry, err := dao.ReadEntry(conn, dn, nil)
+		release()
+		if err != nil {
+			dialog.ShowError(err, t.x.resultWindow)
+			return
+		}
+		if source != "" {
+			t.mu.Lock()
+			if t.sources == nil {
+				t.sources = make(map[*ldap.Entry]string)
+			}
+			t.sources[entry] = source
+			t.mu.Unlock()
+		}
+		t.showEntry(entry)
+	}()
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2/data/binding"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// displayTemplates returns the display templates of a profile, the current
+// profile when name is empty.
+func (x *LdapAdmin) displayTemplates(name string) []config.DisplayTemplate {
+	text := x.profileSetting(name, x.ldapConn.DisplayTemplates, func(data *config.LdapConfData) string {
+		return data.DisplayTemplates
+	})
+	if text == "" {
+		text = config.DefaultDisplayTemplates
+	}
+	return config.ParseDisplayTemplates(text)
+}
+
+// profileSetting reads a setting of a profile, from the config panel for the
+// current profile and from the saved profile otherwise.
+func (x *LdapAdmin) profileSetting(name string, current binding.String, saved func(*config.LdapConfData) string) string {
+	if name != "" {
+		if currentName, _ := x.ldapConn.Name.Get(); name != currentName {
+			if data := x.profiles.Find(name); data != nil {
+				return saved(data)
+			}
+			return ""
+		}
+	}
+	text, _ := current.Get()
+	return text
+}
+
+// templateAttributes returns the attributes the current profile's display
+// templates need, so searches without explicit attributes request them.
+func (x *LdapAdmin) templateAttributes() []string {
+	var res []string
+	for _, tmpl := range x.displayTemplates("") {
+		for _, attr := range tmpl.Attributes() {
+			if !containsFold(res, attr) {
+				res = append(res, attr)
+			}
+		}
+	}
+	return res
+}
+
+// displayName names an entry with the first template whose object class the
+// entry has and that has a value, falling back to cn and then the DN.
+func displayName(entry *ldap.Entry, templates []config.DisplayTemplate) string {
+	classes := entry.GetAttributeValues("objectClass")
+	value := func(attr string) string {
+		if strings.EqualFold(attr, "dn") {
+			return entry.DN
+		}
+		return entry.GetEqualFoldAttributeValue(attr)
+	}
+	for _, tmpl := range templates {
+		if tmpl.Class != "*" && !containsFold(classes, tmpl.Class) {
+			continue
+		}
+		if name, ok := tmpl.Expand(value); ok {
+			return name
+		}
+	}
+	if cn := entry.GetAttributeValue("cn"); cn != "" {
+		return cn
+	}
+	return entry.DN
+}
+
+// entryName is the name of an entry in the result list, using the templates
+// of the profile it came from.
+func (t *resultTab) entryName(entry *ldap.Entry) string {
+	t.mu.Lock()
+	source := t.sources[entry]
+	templates, ok := t.templates[source]
+	t.mu.Unlock()
+	if !ok {
+		templates = t.x.displayTemplates(source)
+		t.mu.Lock()
+		if t.templates == nil {
+			t.templates = make(map[string][]config.DisplayTemplate)
+		}
+		t.templates[source] = templates
+		t.mu.Unlock()
+	}
+	return displayName(entry, templates)
+}
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// valueList edits the values of one attribute, one entry per value, which
+// can be moved up and down and removed.
+type valueList struct {
+	attr    string
+	entries []*widget.Entry
+	box     *fyne.Container
+	label   *fyne.Container // 表单中属性名一列
+	fixed   []string        // 二进制的值不能作为文本编辑, 保存时不变
+	removed bool            // 属性被删除
+}
+
+func newValueList(attr string, values []string) *valueList {
+	l := &valueList{attr: attr, box: container.NewVBox()}
+	for _, v := range values {
+		l.entries = append(l.entries, newValueEntry(v))
+	}
+	l.rebuild()
+	return l
+}
+
+// newFixedValueList keeps binary values as they are, they can only be
+// removed together with the attribute.
+func newFixedValueList(attr string, values []string) *valueList {
+	label := widget.NewLabel(fmt.Sprintf("%d binary values, not editable here", len(values)))
+	label.Importance = widget.LowImportance
+	return &valueList{attr: attr, box: container.NewVBox(label), fixed: values}
+}
+
+func newValueEntry(value string) *widget.Entry {
+	input := widget.NewEntry()
+	input.MultiLine = strings.Contains(value, "\n")
+	input.SetText(value)
+	return input
+}
+
+// values returns the values without empty ones and duplicates, in order
+func (l *valueList) values() []string {
+	if l.removed {
+		return nil
+	}
+	if l.fixed != nil {
+		return l.fixed
+	}
+	var res []string
+	for _, input := range l.entries {
+		if v := input.Text; strings.TrimSpace(v) != "" && !containsString(res, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (l *valueList) add() {
+	input := newValueEntry("")
+	l.entries = append(l.entries, input)
+	l.rebuild()
+	if c := fyne.CurrentApp().Driver().CanvasForObject(l.box); c != nil {
+		c.Focus(input)
+	}
+}
+
+func (l *valueList) remove(input *widget.Entry) {
+	for i, e := range l.entries {
+		if e == input {
+			l.entries = append(l.entries[:i], l.entries[i+1:]...)
+			break
+		}
+	}
+	l.rebuild()
+}
+
+// move moves a value by delta places, staying inside the list
+func (l *valueList) move(input *widget.Entry, delta int) {
+	for i, e := range l.entries {
+		if e != input {
+			continue
+		}
+		j := i + delta
+		if j < 0 || j >= len(l.entries) {
+			return
+		}
+		l.entries[i], l.entries[j] = l.entries[j], l.entries[i]
+		break
+	}
+	l.rebuild()
+}
+
+// setRemoved removes the attribute, or brings it back with its values
+func (l *valueList) setRemoved(removed bool) {
+	l.removed = removed
+	if removed {
+		l.label.Hide()
+		l.box.Hide()
+	} else {
+		l.label.Show()
+		l.box.Show()
+	}
+}
+
+// rebuild lays out a row per value and the add button
+func (l *valueList) rebuild() {
+	l.box.Objects = nil
+	for i, input := range l.entries {
+		up := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { l.move(input, -1) })
+		down := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { l.move(input, 1) })
+		if i == 0 {
+			up.Disable()
+		}
+		if i == len(l.entries)-1 {
+			down.Disable()
+		}
+		remove := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() { l.remove(input) })
+		l.box.Add(container.NewBorder(nil, nil, nil, container.NewHBox(up, down, remove), input))
+	}
+	add := widget.NewButtonWithIcon("Add value", theme.ContentAddIcon(), l.add)
+	add.Importance = widget.LowImportance
+	l.box.Add(container.NewHBox(add))
+	l.box.Refresh()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// attributeForm is the part of the edit dialog that lists the attributes
+type attributeForm struct {
+	lists     []*valueList
+	protected map[string]bool // 不能删除的属性: MUST, objectClass 和 RDN 中的属性
+}
+
+// addRow adds an attribute with a button to remove it, unless it is protected
+func (f *attributeForm) addRow(rows *fyne.Container, list *valueList) {
+	f.lists = append(f.lists, list)
+	remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() { list.setRemoved(true) })
+	remove.Importance = widget.LowImportance
+	if f.protected[strings.ToLower(list.attr)] {
+		remove.Disable()
+	}
+	list.label = container.NewHBox(attributeName(list.attr), remove)
+	rows.Add(list.label)
+	rows.Add(list.box)
+}
+
+// find returns the list of an attribute, removed or not
+func (f *attributeForm) find(attr string) *valueList {
+	for _, list := range f.lists {
+		if strings.EqualFold(list.attr, attr) {
+			return list
+		}
+	}
+	return nil
+}
+
+// protectedAttributes are the attributes of an entry that cannot be removed:
+// objectClass, the MUST attributes of its classes and the attributes in its RDN.
+func protectedAttributes(schema *dao.Schema, entry *ldap.Entry) map[string]bool {
+	res := map[string]bool{"objectclass": true}
+	if schema != nil {
+		must, _ := schema.AllowedAttributes(entry.GetAttributeValues("objectClass"))
+		for _, attr := range must {
+			res[strings.ToLower(attr)] = true
+		}
+	}
+	if dn, err := ldap.ParseDN(entry.DN); err == nil && len(dn.RDNs) > 0 {
+		for _, ava := range dn.RDNs[0].Attributes {
+			res[strings.ToLower(ava.Type)] = true
+		}
+	}
+	return res
+}
+
+// allowedAttributes returns the MUST and MAY attributes of an entry's object
+// classes, sorted, or nil when the schema is not known.
+func allowedAttributes(schema *dao.Schema, entry *ldap.Entry) []string {
+	if schema == nil {
+		return nil
+	}
+	must, may := schema.AllowedAttributes(entry.GetAttributeValues("objectClass"))
+	res := append(must, may...)
+	sort.Slice(res, func(i, j int) bool { return strings.ToLower(res[i]) < strings.ToLower(res[j]) })
+	return res
+}
+
+// newAttributePicker creates the entry to add attributes with. It completes
+// the allowed attributes the entry does not have yet.
+func (f *attributeForm) newAttributePicker(allowed []string, onAdd func(attr string)) fyne.CanvasObject {
+	picker := widget.NewSelectEntry(nil)
+	picker.SetPlaceHolder("Attribute name")
+	if allowed == nil {
+		picker.SetPlaceHolder("Attribute name (schema not loaded, not checked)")
+	}
+	options := func(text string) []string {
+		var res []string
+		for _, attr := range allowed {
+			if list := f.find(attr); list != nil && !list.removed {
+				continue
+			}
+			if strings.Contains(strings.ToLower(attr), strings.ToLower(text)) {
+				res = append(res, attr)
+			}
+		}
+		return res
+	}
+	picker.SetOptions(options(""))
+	picker.OnChanged = func(text string) { picker.SetOptions(options(text)) }
+
+	add := func() {
+		attr := strings.TrimSpace(picker.Text)
+		if attr == "" {
+			return
+		}
+		onAdd(attr)
+		picker.SetText("")
+	}
+	picker.OnSubmitted = func(string) { add() }
+	return container.NewBorder(nil, nil, widget.NewLabel("Add attribute"),
+		widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), add), picker)
+}
+
+// showEditDialog reads an entry again with all its attributes and edits
+// every value. Saving only sends the values that changed.
+func (t *resultTab) showEditDialog(entry *ldap.Entry) {
+	conn, release, err := t.connFor(entry)
+	if err != nil {
+		dialog.ShowError(err, t.x.resultWindow)
+		return
+	}
+	current, err := dao.ReadEntry(conn, entry.DN, nil)
+	release()
+	if err != nil {
+		dialog.ShowError(err, t.x.resultWindow)
+		return
+	}
+
+	t.x.Lock()
+	schema := t.x.schema
+	t.x.Unlock()
+
+	form := &attributeForm{protected: protectedAttributes(schema, current)}
+	sections := container.NewVBox()
+	for _, group := range groupAttributes(current) {
+		rows := container.New(layout.NewFormLayout())
+		for _, attr := range group.attrs {
+			if dao.BinaryKindOf(schema, attr.Name) != dao.NotBinary {
+				form.addRow(rows, newFixedValueList(attr.Name, attr.Values))
+			} else {
+				form.addRow(rows, newValueList(attr.Name, attr.Values))
+			}
+		}
+		sections.Add(widget.NewCard(group.name, "", rows))
+	}
+
+	// 新增的属性放在最后, 添加第一个时才显示
+	addedRows := container.New(layout.NewFormLayout())
+	added := widget.NewCard("Added Attributes", "", addedRows)
+	added.Hide()
+	sections.Add(added)
+
+	allowed := allowedAttributes(schema, current)
+	picker := form.newAttributePicker(allowed, func(attr string) {
+		if list := form.find(attr); list != nil {
+			if !list.removed {
+				dialog.ShowInformation("Add Attribute", fmt.Sprintf("The entry already has %s", list.attr), t.x.resultWindow)
+				return
+			}
+			list.setRemoved(false)
+			return
+		}
+		if allowed != nil {
+			name := ""
+			for _, a := range allowed {
+				if strings.EqualFold(a, attr) {
+					name = a
+				}
+			}
+			if name == "" {
+				dialog.ShowError(fmt.Errorf("%s is not allowed by the object classes of the entry", attr), t.x.resultWindow)
+				return
+			}
+			attr = name
+		}
+		list := newValueList(attr, nil)
+		form.addRow(addedRows, list)
+		added.Show()
+		list.add()
+	})
+
+	var editDialog dialog.Dialog
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		req := ldap.NewModifyRequest(current.DN, nil)
+		for _, list := range form.lists {
+			if form.protected[strings.ToLower(list.attr)] && len(list.values()) == 0 {
+				dialog.ShowError(fmt.Errorf("%s is required and needs at least one value", list.attr), t.x.resultWindow)
+				return
+			}
+			dao.AddValueChanges(req, list.attr, current.GetAttributeValues(list.attr), list.values())
+		}
+		if len(req.Changes) == 0 {
+			dialog.ShowInformation("Edit Entry", "Nothing was changed", t.x.resultWindow)
+			return
+		}
+
+		conn, release, err := t.connFor(entry)
+		if err != nil {
+			dialog.ShowError(err, t.x.resultWindow)
+			return
+		}
+		defer release()
+		if err := conn.Modify(req); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to modify entry: %w", err), t.x.resultWindow)
+			return
+		}
+		editDialog.Hide()
+
+		// 重新读取, 以便显示修改后的值
+		fresh, err := dao.ReadEntry(conn, entry.DN, t.x.searchOptions(t.query).Attributes)
+		if err != nil {
+			t.rerun(true)
+			return
+		}
+		t.replaceEntries(map[*ldap.Entry]*ldap.Entry{entry: fresh})
+	})
+	saveButton.Importance = widget.HighImportance
+
+	content := container.NewBorder(picker, container.NewHBox(layout.NewSpacer(), saveButton), nil, nil,
+		container.NewVScroll(sections))
+	editDialog = dialog.NewCustom("Edit "+current.DN, "Close", content, t.x.resultWindow)
+	editDialog.Resize(fyne.NewSize(800, 600))
+	editDialog.Show()
+}
@@ -21,6 +21,23 @@ func (x *List) MinSize() fyne.Size {
 }
 
 func NewList(length func() int, createItem func() fyne.CanvasObject, updateItem func(widget.ListItemID, fyne.CanvasObject)) *List {
-	list := &List{List: *widget.NewList(length, createItem, updateItem)}
+	list := &List{}
+	list.Length = length
+	list.CreateItem = createItem
+	list.UpdateItem = updateItem
+	list.ExtendBaseWidget(list)
 	return list
 }
+
+// ToolbarObject puts any canvas object, such as an entry, into a widget.Toolbar
+type ToolbarObject struct {
+	Object fyne.CanvasObject
+}
+
+func (t *ToolbarObject) ToolbarObject() fyne.CanvasObject {
+	return t.Object
+}
+
+func NewToolbarObject(object fyne.CanvasObject) *ToolbarObject {
+	return &ToolbarObject{Object: object}
+}
@@ -0,0 +1,100 @@
+package ext
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// shortcutNames are the shortcuts added with AddShortcut. A focused widget
+// gets the shortcuts instead of its canvas, the widgets of this package pass
+// these on to the canvas.
+var shortcutNames = make(map[string]bool)
+
+// AddShortcut adds a shortcut to a canvas. A shortcut without modifiers is
+// run when its key is typed while nothing has the focus, see HandleKeys.
+func AddShortcut(c fyne.Canvas, s fyne.Shortcut, fn func(fyne.Shortcut)) {
+	shortcutNames[s.ShortcutName()] = true
+	c.AddShortcut(s, fn)
+}
+
+// HandleKeys runs the shortcuts without modifiers of a canvas for the keys
+// typed while nothing has the focus.
+func HandleKeys(c fyne.Canvas) {
+	c.SetOnTypedKey(func(e *fyne.KeyEvent) {
+		typeShortcut(c, &desktop.CustomShortcut{KeyName: e.Name})
+	})
+}
+
+// typeShortcut runs a shortcut added with AddShortcut, false means there is none
+func typeShortcut(c fyne.Canvas, s fyne.Shortcut) bool {
+	if c == nil || !shortcutNames[s.ShortcutName()] {
+		return false
+	}
+	handler, ok := c.(fyne.Shortcutable)
+	if !ok {
+		return false
+	}
+	handler.TypedShortcut(s)
+	return true
+}
+
+func canvasFor(obj fyne.CanvasObject) fyne.Canvas {
+	return fyne.CurrentApp().Driver().CanvasForObject(obj)
+}
+
+// Entry is an entry that leaves the shortcuts added with AddShortcut and the
+// function keys to its canvas.
+type Entry struct {
+	widget.Entry
+	// OnKey is called before the entry handles a key, true means it was handled
+	OnKey func(key fyne.KeyName) bool
+}
+
+func NewEntry() *Entry {
+	e := &Entry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *Entry) TypedKey(key *fyne.KeyEvent) {
+	if e.OnKey != nil && e.OnKey(key.Name) {
+		return
+	}
+	if isFunctionKey(key.Name) && typeShortcut(canvasFor(e), &desktop.CustomShortcut{KeyName: key.Name}) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+func (e *Entry) TypedShortcut(s fyne.Shortcut) {
+	if typeShortcut(canvasFor(e), s) {
+		return
+	}
+	e.Entry.TypedShortcut(s)
+}
+
+func isFunctionKey(key fyne.KeyName) bool {
+	switch key {
+	case fyne.KeyF1, fyne.KeyF2, fyne.KeyF3, fyne.KeyF4, fyne.KeyF5, fyne.KeyF6,
+		fyne.KeyF7, fyne.KeyF8, fyne.KeyF9, fyne.KeyF10, fyne.KeyF11, fyne.KeyF12:
+		return true
+	}
+	return false
+}
+
+// Table is a table that leaves the keyboard to its canvas. Like List, tapping
+// a cell does not focus it, as the focus goes to the embedded widget.Table,
+// which is not in the object tree, so the typed keys reach HandleKeys.
+type Table struct {
+	widget.Table
+}
+
+func NewTable(length func() (int, int), create func() fyne.CanvasObject, update func(widget.TableCellID, fyne.CanvasObject)) *Table {
+	t := &Table{}
+	t.Length = length
+	t.CreateCell = create
+	t.UpdateCell = update
+	t.ExtendBaseWidget(t)
+	return t
+}
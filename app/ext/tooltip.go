@@ -0,0 +1,98 @@
+package ext
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// tipOffset places a tooltip below the mouse pointer
+var tipOffset = fyne.NewPos(0, 20)
+
+// ToolTipContainer is the window content that TipLabels draw their tooltips
+// on. A pop-up would take the hover away from the label, so the tooltip is
+// drawn in a layer above the content instead.
+type ToolTipContainer struct {
+	widget.BaseWidget
+	Content fyne.CanvasObject
+	tip     *widget.Label
+	box     *fyne.Container
+	layer   *fyne.Container
+}
+
+func NewToolTipContainer(content fyne.CanvasObject) *ToolTipContainer {
+	t := &ToolTipContainer{Content: content, tip: widget.NewLabel("")}
+	background := canvas.NewRectangle(theme.Color(theme.ColorNameOverlayBackground))
+	background.StrokeColor = theme.Color(theme.ColorNameShadow)
+	background.StrokeWidth = 1
+	t.box = container.NewStack(background, t.tip)
+	t.box.Hide()
+	t.layer = container.NewWithoutLayout(t.box)
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *ToolTipContainer) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(t.Content, t.layer))
+}
+
+// ShowToolTip shows text at a position of the canvas, kept inside the window
+func (t *ToolTipContainer) ShowToolTip(text string, pos fyne.Position) {
+	t.tip.SetText(text)
+	size := t.box.MinSize()
+	t.box.Resize(size)
+	if max := t.Size().Width - size.Width; pos.X > max {
+		pos.X = max
+	}
+	if max := t.Size().Height - size.Height; pos.Y > max {
+		pos.Y = max
+	}
+	t.box.Move(pos)
+	t.box.Show()
+}
+
+func (t *ToolTipContainer) HideToolTip() {
+	t.box.Hide()
+}
+
+// TipLabel is a label that shows ToolTip while the mouse is over it, when
+// its window content is a ToolTipContainer.
+type TipLabel struct {
+	widget.Label
+	ToolTip string
+}
+
+func NewTipLabel(text, tip string) *TipLabel {
+	l := &TipLabel{ToolTip: tip}
+	l.Text = text
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *TipLabel) MouseIn(e *desktop.MouseEvent) {
+	l.MouseMoved(e)
+}
+
+func (l *TipLabel) MouseMoved(e *desktop.MouseEvent) {
+	if t := l.container(); t != nil && l.ToolTip != "" {
+		t.ShowToolTip(l.ToolTip, e.AbsolutePosition.Add(tipOffset))
+	}
+}
+
+func (l *TipLabel) MouseOut() {
+	if t := l.container(); t != nil {
+		t.HideToolTip()
+	}
+}
+
+func (l *TipLabel) container() *ToolTipContainer {
+	c := fyne.CurrentApp().Driver().CanvasForObject(l)
+	if c == nil {
+		return nil
+	}
+	t, _ := c.Content().(*ToolTipContainer)
+	return t
+}
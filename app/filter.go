@@ -0,0 +1,152 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// 括号按嵌套层级轮换的颜色
+var filterDepthColors = []fyne.ThemeColorName{
+	theme.ColorNamePrimary,
+	theme.ColorNameSuccess,
+	theme.ColorNameWarning,
+	theme.ColorNameHyperlink,
+}
+
+// initFilterValidation creates the highlight and status widgets shown under the
+// filter entry and validates the filter every time it changes.
+func (x *LdapAdmin) initFilterValidation() {
+	x.filterHighlight = widget.NewRichText()
+	x.filterStatus = widget.NewLabel("")
+	x.filterStatus.Wrapping = fyne.TextWrapWord
+	x.searchReq.Filter.AddListener(binding.NewDataListener(x.validateFilter))
+}
+
+// validateFilter re-renders the filter highlight, shows syntax errors and
+// unknown attributes, and only enables searching for a valid filter.
+func (x *LdapAdmin) validateFilter() {
+	if x.filterHighlight == nil || x.searchButton == nil {
+		return
+	}
+	filter, _ := x.searchReq.Filter.Get()
+	attrs, err := dao.ParseFilter(filter)
+
+	var filterErr *dao.FilterError
+	errPos := -1
+	if errors.As(err, &filterErr) {
+		errPos = filterErr.Pos
+	}
+	x.filterHighlight.Segments = highlightFilter(filter, errPos)
+	x.filterHighlight.Refresh()
+
+	if err != nil {
+		if errPos < 0 {
+			x.filterStatus.SetText(fmt.Sprintf("Invalid filter: %v", err))
+		} else {
+			x.filterStatus.SetText(fmt.Sprintf("Invalid filter at %v", err))
+		}
+		x.filterStatus.Importance = widget.DangerImportance
+		x.filterStatus.Refresh()
+		x.searchButton.Disable()
+		x.federatedButton.Disable()
+		return
+	}
+	x.searchButton.Enable()
+	x.federatedButton.Enable()
+
+	if unknown := x.unknownAttributes(attrs); len(unknown) > 0 {
+		x.filterStatus.SetText(fmt.Sprintf("Warning: not in server schema: %s", strings.Join(unknown, ", ")))
+		x.filterStatus.Importance = widget.WarningImportance
+	} else {
+		x.filterStatus.SetText("")
+		x.filterStatus.Importance = widget.MediumImportance
+	}
+	x.filterStatus.Refresh()
+}
+
+// highlightFilter colors each parenthesis by its nesting depth and marks the
+// character at errPos, if any, as an error.
+func highlightFilter(filter string, errPos int) []widget.RichTextSegment {
+	var segments []widget.RichTextSegment
+	add := func(text string, color fyne.ThemeColorName, bold bool) {
+		if text == "" {
+			return
+		}
+		segments = append(segments, &widget.TextSegment{
+			Text: text,
+			Style: widget.RichTextStyle{
+				ColorName: color,
+				Inline:    true,
+				SizeName:  theme.SizeNameText,
+				TextStyle: fyne.TextStyle{Monospace: true, Bold: bold},
+			},
+		})
+	}
+
+	depth := 0
+	start := 0
+	n := 0 // 已经过的字符数, errPos 按字符计
+	for i, c := range filter {
+		pos := n
+		n++
+		if pos != errPos && c != '(' && c != ')' {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(filter[i:])
+		add(filter[start:i], theme.ColorNameForeground, false)
+		start = i + size
+		switch {
+		case pos == errPos:
+			add(filter[i:i+size], theme.ColorNameError, true)
+			if c == '(' {
+				depth++
+			} else if c == ')' && depth > 0 {
+				depth--
+			}
+		case c == '(':
+			add("(", filterDepthColors[depth%len(filterDepthColors)], true)
+			depth++
+		default:
+			if depth == 0 {
+				add(")", theme.ColorNameError, true)
+				continue
+			}
+			depth--
+			add(")", filterDepthColors[depth%len(filterDepthColors)], true)
+		}
+	}
+	add(filter[start:], theme.ColorNameForeground, false)
+	if errPos >= n {
+		// the error is at the end, e.g. a missing ')'
+		add("␣", theme.ColorNameError, true)
+	}
+	return segments
+}
+
+// unknownAttributes returns the attributes that are not defined in the server
+// schema. Nothing is reported until the schema has been loaded.
+func (x *LdapAdmin) unknownAttributes(attrs []string) (unknown []string) {
+	x.Lock()
+	schema := x.schema
+	x.Unlock()
+	if schema == nil {
+		return nil
+	}
+	for _, attr := range attrs {
+		if attr == "" || attr[0] >= '0' && attr[0] <= '9' {
+			continue // OIDs are not checked
+		}
+		if schema.AttributeType(attr) == nil {
+			unknown = append(unknown, attr)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// classIconResources are the icons the object classes can be mapped to
+var classIconResources = map[string]func() fyne.Resource{
+	"account":     theme.AccountIcon,
+	"group":       theme.ListIcon,
+	"folder":      theme.FolderIcon,
+	"home":        theme.HomeIcon,
+	"computer":    theme.ComputerIcon,
+	"storage":     theme.StorageIcon,
+	"alias":       theme.MailForwardIcon,
+	"mail":        theme.MailComposeIcon,
+	"settings":    theme.SettingsIcon,
+	"document":    theme.DocumentIcon,
+	"application": theme.FileApplicationIcon,
+	"file":        theme.FileIcon,
+}
+
+// badgeColors are the colors of the badges shown next to entry names
+var badgeColors = map[dao.Badge]fyne.ThemeColorName{
+	dao.BadgeDisabled: theme.ColorNameError,
+	dao.BadgeLocked:   theme.ColorNameWarning,
+	dao.BadgeAlias:    theme.ColorNamePrimary,
+	dao.BadgeChildren: theme.ColorNamePlaceHolder,
+}
+
+// iconNames lists the icon names for the config panel
+func iconNames() []string {
+	names := make([]string, 0, len(classIconResources))
+	for name := range classIconResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// classIcons returns the object class icons of a profile, the current
+// profile when name is empty.
+func (x *LdapAdmin) classIcons(name string) []config.ClassIcon {
+	text := x.profileSetting(name, x.ldapConn.ClassIcons, func(data *config.LdapConfData) string {
+		return data.ClassIcons
+	})
+	if text == "" {
+		text = config.DefaultClassIcons
+	}
+	return config.ParseClassIcons(text)
+}
+
+// entryIcon returns the icon of the first mapping whose object class the
+// entry has. Unknown icon names are skipped, the fallback is the file icon.
+func entryIcon(entry *ldap.Entry, icons []config.ClassIcon) fyne.Resource {
+	classes := entry.GetAttributeValues("objectClass")
+	for _, icon := range icons {
+		if icon.Class != "*" && !containsFold(classes, icon.Class) {
+			continue
+		}
+		if res, ok := classIconResources[icon.Icon]; ok {
+			return res()
+		}
+	}
+	return theme.FileIcon()
+}
+
+// setBadges shows the badges of an entry, leaving out the ones in skip
+func setBadges(text *widget.RichText, badges []dao.Badge, skip ...dao.Badge) {
+	var segments []widget.RichTextSegment
+	for _, badge := range badges {
+		if containsBadge(skip, badge) {
+			continue
+		}
+		segments = append(segments, &widget.TextSegment{
+			Text: string(badge),
+			Style: widget.RichTextStyle{
+				ColorName: badgeColors[badge],
+				Inline:    true,
+				SizeName:  theme.SizeNameCaptionText,
+				TextStyle: fyne.TextStyle{Bold: true},
+			},
+		}, &widget.TextSegment{Text: " ", Style: widget.RichTextStyleInline})
+	}
+	text.Segments = segments
+	text.Refresh()
+}
+
+func containsBadge(badges []dao.Badge, badge dao.Badge) bool {
+	for _, b := range badges {
+		if b == badge {
+			return true
+		}
+	}
+	return false
+}
+
+// entryIcon is the icon of an entry in the result list, using the mapping
+// of the profile it came from.
+func (t *resultTab) entryIcon(entry *ldap.Entry) fyne.Resource {
+	t.mu.Lock()
+	source := t.sources[entry]
+	icons, ok := t.icons[source]
+	t.mu.Unlock()
+	if !ok {
+		icons = t.x.classIcons(source)
+		t.mu.Lock()
+		if t.icons == nil {
+			t.icons = make(map[string][]config.ClassIcon)
+		}
+		t.icons[source] = icons
+		t.mu.Unlock()
+	}
+	return entryIcon(entry, icons)
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// moveEntryShow asks for the new RDN and parent of an entry and sends a
+// ModifyDN request. onMoved gets the old and the new DN.
+func (x *LdapAdmin) moveEntryShow(dn, source string, parent fyne.Window, onMoved func(oldDN, newDN string)) {
+	if current, _ := x.ldapConn.Name.Get(); source == current {
+		source = "" // 当前配置的条目也在目录树中
+	}
+	rdn, oldParent := dao.SplitDN(dn)
+
+	newRDN := widget.NewEntry()
+	newRDN.SetText(rdn)
+	deleteOld := widget.NewCheck("Remove the old RDN value from the entry", nil)
+	deleteOld.SetChecked(true)
+	superior := widget.NewEntry()
+	superior.SetText(oldParent)
+	preview := widget.NewLabel("")
+	preview.Wrapping = fyne.TextWrapBreak
+	update := func(string) {
+		newDN := strings.TrimSpace(newRDN.Text)
+		if p := strings.TrimSpace(superior.Text); p != "" {
+			newDN += "," + p
+		}
+		preview.SetText(newDN)
+	}
+	newRDN.OnChanged = update
+	superior.OnChanged = update
+	update("")
+	browse := widget.NewButtonWithIcon("Browse...", theme.FolderOpenIcon(), func() {
+		x.pickDN("Choose New Parent", source, strings.TrimSpace(superior.Text), parent, superior.SetText)
+	})
+
+	current := widget.NewLabel(dn)
+	current.Wrapping = fyne.TextWrapBreak
+	form := widget.NewForm(
+		widget.NewFormItem("Entry", current),
+		widget.NewFormItem("New RDN", newRDN),
+		widget.NewFormItem("", deleteOld),
+		widget.NewFormItem("New parent", container.NewBorder(nil, nil, nil, browse, superior)),
+		widget.NewFormItem("New DN", preview),
+	)
+
+	var d dialog.Dialog
+	ok := widget.NewButtonWithIcon("Rename", theme.ConfirmIcon(), func() {
+		rdnText := strings.TrimSpace(newRDN.Text)
+		newSuperior := strings.TrimSpace(superior.Text)
+		if err := validateMove(dn, rdnText, newSuperior); err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		if strings.EqualFold(newSuperior, oldParent) {
+			newSuperior = "" // 只改名, 不需要 newSuperior
+		}
+		go func() {
+			conn, release, err := x.connFor(source)
+			if err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			newDN, err := dao.ModifyDN(conn, dn, rdnText, deleteOld.Checked, newSuperior)
+			release()
+			if err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			d.Hide()
+			if source == "" {
+				x.reloadTreeNode(oldParent)
+				if newSuperior != "" {
+					x.reloadTreeNode(newSuperior)
+				}
+			}
+			onMoved(dn, newDN)
+		}()
+	})
+	ok.Importance = widget.HighImportance
+	d = dialog.NewCustomWithoutButtons("Rename or Move Entry", container.NewBorder(nil,
+		container.NewHBox(layout.NewSpacer(), widget.NewButton("Cancel", func() { d.Hide() }), ok), nil, nil, form), parent)
+	d.Resize(fyne.NewSize(650, 0))
+	d.Show()
+}
+
+// validateMove checks the new RDN and parent of an entry before sending them
+func validateMove(dn, newRDN, newSuperior string) error {
+	parsed, err := ldap.ParseDN(newRDN)
+	if err != nil || len(parsed.RDNs) != 1 {
+		return fmt.Errorf("%q is not a valid RDN, write it as attribute=value", newRDN)
+	}
+	if newSuperior != "" {
+		if _, err := ldap.ParseDN(newSuperior); err != nil {
+			return fmt.Errorf("invalid parent DN: %w", err)
+		}
+		if dao.IsBelow(newSuperior, dn) {
+			return fmt.Errorf("an entry cannot be moved below itself")
+		}
+	}
+	rdn, oldParent := dao.SplitDN(dn)
+	if rdn == newRDN && strings.EqualFold(newSuperior, oldParent) {
+		return fmt.Errorf("the new RDN and parent are the same as the current ones")
+	}
+	return nil
+}
+
+// pickDN lets the user choose an entry from a directory tree of its own.
+// The tree of the current profile starts at the naming contexts, others at
+// the start DN.
+func (x *LdapAdmin) pickDN(title, source, start string, parent fyne.Window, onPicked func(dn string)) {
+	var roots []string
+	if source == "" {
+		x.Lock()
+		if x.rootDSE != nil {
+			roots = x.rootDSE.NamingContexts
+		}
+		x.Unlock()
+	}
+	if len(roots) == 0 && start != "" {
+		roots = []string{start}
+	}
+
+	var mu sync.Mutex
+	children := make(map[string][]string)
+	leaves := make(map[string]bool)
+
+	var tree *widget.Tree
+	load := func(uid string) {
+		mu.Lock()
+		_, loaded := children[uid]
+		mu.Unlock()
+		if loaded || uid == "" {
+			return
+		}
+		conn, release, err := x.connFor(source)
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		entries, err := dao.ListChildren(conn, uid)
+		release()
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		mu.Lock()
+		dns := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			dns = append(dns, entry.DN)
+			leaves[entry.DN] = !dao.HasChildren(entry)
+		}
+		children[uid] = dns
+		leaves[uid] = len(dns) == 0
+		mu.Unlock()
+		tree.Refresh()
+	}
+
+	selected := widget.NewLabel(start)
+	selected.Truncation = fyne.TextTruncateEllipsis
+	tree = widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			if uid == "" {
+				return roots
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return children[uid]
+		},
+		func(uid widget.TreeNodeID) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return !leaves[uid]
+		},
+		func(bool) fyne.CanvasObject {
+			return container.NewHBox(widget.NewIcon(theme.FolderIcon()), widget.NewLabel("Template"))
+		},
+		func(uid widget.TreeNodeID, branch bool, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			icon := theme.FolderIcon()
+			if !branch {
+				icon = theme.FileIcon()
+			}
+			box.Objects[0].(*widget.Icon).SetResource(icon)
+			label := uid
+			if !containsString(roots, uid) {
+				label, _ = dao.SplitDN(uid)
+			}
+			box.Objects[1].(*widget.Label).SetText(label)
+		},
+	)
+	tree.OnBranchOpened = func(uid widget.TreeNodeID) { go load(uid) }
+	tree.OnSelected = func(uid widget.TreeNodeID) { selected.SetText(uid) }
+
+	var d dialog.Dialog
+	choose := widget.NewButtonWithIcon("Choose", theme.ConfirmIcon(), func() {
+		if selected.Text == "" {
+			return
+		}
+		d.Hide()
+		onPicked(selected.Text)
+	})
+	choose.Importance = widget.HighImportance
+	d = dialog.NewCustomWithoutButtons(title, container.NewBorder(nil,
+		container.NewBorder(nil, nil, nil, container.NewHBox(widget.NewButton("Cancel", func() { d.Hide() }), choose), selected),
+		nil, nil, tree), parent)
+	d.Resize(fyne.NewSize(500, 500))
+	d.Show()
+}
+
+// moveTreeSelection renames or moves the entry selected in the directory tree
+func (x *LdapAdmin) moveTreeSelection() {
+	dn := x.treeSelected
+	if dn == "" {
+		return
+	}
+	x.moveEntryShow(dn, "", x.windows, func(oldDN, newDN string) {
+		x.treeSelected = newDN
+		x.entryMoved("", oldDN, newDN)
+	})
+}
+
+// moveCurrent renames or moves the entry shown in the detail view, or the
+// only selected one
+func (t *resultTab) moveCurrent() {
+	entry := t.detailEntry()
+	if selected := t.selectedEntries(); len(selected) == 1 {
+		entry = selected[0]
+	}
+	if entry == nil {
+		return
+	}
+	source := t.sourceOf(entry)
+	t.x.moveEntryShow(entry.DN, source, t.x.resultWindow, func(oldDN, newDN string) {
+		t.x.entryMoved(source, oldDN, newDN)
+	})
+}
+
+// entryMoved updates every result tab after an entry of a profile, empty for
+// the current one, was renamed or moved, as several tabs can hold it
+func (x *LdapAdmin) entryMoved(source, oldDN, newDN string) {
+	current, _ := x.ldapConn.Name.Get()
+	if source == current {
+		source = ""
+	}
+	for _, t := range x.tabList() {
+		t.entryMoved(source, current, oldDN, newDN)
+	}
+}
+
+// entryMoved updates the results in place after an entry was renamed or
+// moved: the entry is read again, as its RDN attribute changed, and the
+// entries below it get their new DNs. Only entries from the source profile
+// are changed, current is the name of the current profile.
+func (t *resultTab) entryMoved(source, current, oldDN, newDN string) {
+	t.mu.Lock()
+	selectData := t.selectData
+	var entries []*ldap.Entry
+	for _, entry := range slices.Concat(t.data, []*ldap.Entry{selectData}) {
+		if from := t.sources[entry]; from == source || from == current && source == "" {
+			entries = append(entries, entry)
+		}
+	}
+	inData := slices.Contains(t.data, selectData)
+	t.mu.Unlock()
+
+	updated := make(map[*ldap.Entry]*ldap.Entry)
+	for _, entry := range entries {
+		if entry == nil || !dao.IsBelow(entry.DN, oldDN) || len(entry.DN) < len(oldDN) {
+			continue
+		}
+		moved := *entry
+		moved.DN = entry.DN[:len(entry.DN)-len(oldDN)] + newDN
+		if len(entry.DN) == len(oldDN) {
+			if conn, release, err := t.x.connFor(source); err == nil {
+				fresh, err := dao.ReadEntry(conn, newDN, t.x.searchOptions(t.query).Attributes)
+				release()
+				if err == nil {
+					updated[entry] = fresh
+					continue
+				}
+			}
+		}
+		updated[entry] = &moved
+	}
+	t.replaceEntries(updated)
+	// 详情中的条目不在结果列表里时, 列表刷新不会更新详情
+	if _, ok := updated[selectData]; ok && !inData {
+		t.refreshDetailView()
+	}
+}
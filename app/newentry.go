@@ -0,0 +1,336 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// entryTemplate presets the object classes, naming attribute and form
+// fields of a new entry
+type entryTemplate struct {
+	name    string
+	classes []string
+	rdn     string
+	attrs   []string // 除 MUST 外在表单中列出的属性
+}
+
+// entryClone is the template made from an entry that is cloned
+type entryClone struct {
+	entryTemplate
+	values  map[string][]string // 预填的值, 键为小写属性名
+	fill    []config.CloneRule  // 创建时仍为空就按模板填写的属性
+	profile string              // 原条目所在的配置, 副本也创建在这里, 空为当前配置
+}
+
+var entryTemplates = []entryTemplate{
+	{"Person (inetOrgPerson)", []string{"top", "person", "organizationalPerson", "inetOrgPerson"}, "uid",
+		[]string{"cn", "sn", "givenName", "displayName", "mail", "telephoneNumber"}},
+	{"POSIX account", []string{"top", "person", "organizationalPerson", "inetOrgPerson", "posixAccount", "shadowAccount"}, "uid",
+		[]string{"cn", "sn", "uidNumber", "gidNumber", "homeDirectory", "loginShell", "mail"}},
+	{"Group (groupOfNames)", []string{"top", "groupOfNames"}, "cn", []string{"member", "description"}},
+	{"Group (groupOfUniqueNames)", []string{"top", "groupOfUniqueNames"}, "cn", []string{"uniqueMember", "description"}},
+	{"POSIX group", []string{"top", "posixGroup"}, "cn", []string{"gidNumber", "memberUid", "description"}},
+	{"Organizational unit", []string{"top", "organizationalUnit"}, "ou", []string{"description"}},
+	{"Organization", []string{"top", "organization"}, "o", []string{"description"}},
+	{"Device", []string{"top", "device"}, "cn", []string{"serialNumber", "description"}},
+	{"Custom", nil, "cn", nil},
+}
+
+// NewEntryShow opens the wizard that creates an entry, below the base DN of
+// the search panel unless another parent is chosen.
+func (x *LdapAdmin) NewEntryShow() {
+	x.newEntryWizard(nil, strings.TrimSpace(x.currentSearch().BaseDN), x.windows)
+}
+
+// newEntryWizard shows the wizard in a window. A clone is offered as the
+// first template, with the values of the entry it copies.
+func (x *LdapAdmin) newEntryWizard(clone *entryClone, parentDN string, w fyne.Window) {
+	templates := entryTemplates
+	title := "New Entry"
+	profile := ""
+	if clone != nil {
+		templates = append([]entryTemplate{clone.entryTemplate}, entryTemplates...)
+		title = "Clone Entry"
+		profile = clone.profile
+	}
+	profileName := x.profileName(profile)
+
+	x.Lock()
+	schema := x.schema
+	var namingContexts []string
+	if x.rootDSE != nil {
+		namingContexts = x.rootDSE.NamingContexts
+	}
+	x.Unlock()
+
+	holder := container.NewStack()
+	wizard := dialog.NewCustomWithoutButtons(title, holder, w)
+
+	// 第一步: 上级 DN, objectClass 和 RDN 属性
+	parent := widget.NewSelectEntry(namingContexts)
+	parent.SetText(parentDN)
+	classes := widget.NewEntry()
+	classes.SetPlaceHolder("Object classes, comma separated")
+	rdn := widget.NewSelectEntry(nil)
+	tmpl := templates[0]
+	templateSelect := widget.NewSelect(nil, func(name string) {
+		for _, t := range templates {
+			if t.name == name {
+				tmpl = t
+			}
+		}
+		classes.SetText(strings.Join(tmpl.classes, ", "))
+		rdn.SetText(tmpl.rdn)
+	})
+	for _, t := range templates {
+		templateSelect.Options = append(templateSelect.Options, t.name)
+	}
+	classes.OnChanged = func(text string) {
+		if schema != nil {
+			must, may := schema.AllowedAttributes(splitAttributes(text))
+			rdn.SetOptions(append(must, may...))
+		}
+	}
+	templateSelect.SetSelected(tmpl.name)
+
+	var showForm func(parentDN string, objectClasses []string, rdnAttr string)
+	next := widget.NewButtonWithIcon("Next", theme.NavigateNextIcon(), func() {
+		parentDN := strings.TrimSpace(parent.Text)
+		objectClasses := splitAttributes(classes.Text)
+		rdnAttr := strings.TrimSpace(rdn.Text)
+		if err := validateNewEntry(schema, parentDN, objectClasses, rdnAttr); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		showForm(parentDN, objectClasses, rdnAttr)
+	})
+	next.Importance = widget.HighImportance
+	step1 := container.NewBorder(nil,
+		container.NewHBox(layout.NewSpacer(), widget.NewButton("Cancel", wizard.Hide), next), nil, nil,
+		widget.NewForm(
+			widget.NewFormItem("Created in", widget.NewLabelWithStyle(profileName, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+			widget.NewFormItem("Template", templateSelect),
+			widget.NewFormItem("Parent DN", parent),
+			widget.NewFormItem("Object classes", classes),
+			widget.NewFormItem("RDN attribute", rdn),
+		),
+	)
+
+	// 第二步: 属性表单
+	showForm = func(parentDN string, objectClasses []string, rdnAttr string) {
+		draft := &ldap.Entry{Attributes: []*ldap.EntryAttribute{ldap.NewEntryAttribute("objectClass", objectClasses)}}
+		form := &attributeForm{protected: protectedAttributes(schema, draft)}
+		var prefill *entryClone
+		if clone != nil && tmpl.name == clone.name {
+			prefill = clone
+		}
+		form.protected[strings.ToLower(rdnAttr)] = true
+
+		rows := container.New(layout.NewFormLayout())
+		fields := []string{rdnAttr}
+		if schema != nil {
+			must, _ := schema.AllowedAttributes(objectClasses)
+			fields = append(fields, must...)
+		}
+		fields = append(fields, tmpl.attrs...)
+		allowed := allowedAttributes(schema, draft)
+		for _, attr := range fields {
+			if strings.EqualFold(attr, "objectClass") || form.find(attr) != nil {
+				continue
+			}
+			if allowed != nil && !containsFold(allowed, attr) {
+				continue // 模板中的属性不属于所选的 objectClass
+			}
+			form.addRow(rows, prefill.valueList(schema, attr))
+		}
+		picker := form.newAttributePicker(allowed, func(attr string) {
+			if list := form.find(attr); list != nil {
+				list.setRemoved(false)
+				return
+			}
+			if allowed != nil && !containsFold(allowed, attr) {
+				dialog.ShowError(fmt.Errorf("%s is not allowed by the object classes %s", attr, strings.Join(objectClasses, ", ")), w)
+				return
+			}
+			list := newValueList(attr, nil)
+			form.addRow(rows, list)
+			list.add()
+		})
+
+		dnLabel := widget.NewLabel("")
+		dnLabel.Wrapping = fyne.TextWrapBreak
+		create := widget.NewButtonWithIcon("Create", theme.ConfirmIcon(), func() {
+			if prefill != nil {
+				form.fillTemplates(prefill.fill)
+			}
+			dn, attributes, err := form.newEntry(schema, parentDN, objectClasses, rdnAttr)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			dnLabel.SetText("DN: " + dn)
+			go x.createEntry(profile, dn, parentDN, attributes, wizard, w)
+		})
+		create.Importance = widget.HighImportance
+		back := widget.NewButtonWithIcon("Back", theme.NavigateBackIcon(), func() {
+			holder.Objects = []fyne.CanvasObject{step1}
+			holder.Refresh()
+		})
+
+		header := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("New %s below %s, in %s", strings.Join(objectClasses, ", "), parentDN, profileName)),
+			picker,
+		)
+		holder.Objects = []fyne.CanvasObject{container.NewBorder(header,
+			container.NewBorder(nil, nil, nil, container.NewHBox(back, widget.NewButton("Cancel", wizard.Hide), create), dnLabel),
+			nil, nil, container.NewVScroll(rows))}
+		holder.Refresh()
+	}
+
+	holder.Objects = []fyne.CanvasObject{step1}
+	wizard.Resize(fyne.NewSize(700, 600))
+	wizard.Show()
+}
+
+// valueList creates the form row of an attribute, with the copied values of a
+// clone or a single empty value when c is nil
+func (c *entryClone) valueList(schema *dao.Schema, attr string) *valueList {
+	if c == nil {
+		return newValueList(attr, []string{""})
+	}
+	values := c.values[strings.ToLower(attr)]
+	if len(values) > 0 && dao.BinaryKindOf(schema, attr) != dao.NotBinary {
+		return newFixedValueList(attr, values)
+	}
+	if len(values) == 0 {
+		values = []string{""}
+	}
+	list := newValueList(attr, values)
+	for _, rule := range c.fill {
+		if strings.EqualFold(rule.Attr, attr) {
+			list.entries[0].SetPlaceHolder("Filled in from " + rule.Rule)
+		}
+	}
+	return list
+}
+
+// validateNewEntry checks the first step of the wizard
+func validateNewEntry(schema *dao.Schema, parentDN string, objectClasses []string, rdnAttr string) error {
+	if parentDN == "" {
+		return fmt.Errorf("the parent DN is empty")
+	}
+	if _, err := ldap.ParseDN(parentDN); err != nil {
+		return fmt.Errorf("invalid parent DN: %w", err)
+	}
+	if len(objectClasses) == 0 {
+		return fmt.Errorf("choose at least one object class")
+	}
+	if rdnAttr == "" {
+		return fmt.Errorf("choose the attribute that names the entry")
+	}
+	if schema == nil {
+		return nil // 没有 schema 时由服务器检查
+	}
+	structural := false
+	for _, class := range objectClasses {
+		oc := schema.ObjectClass(class)
+		if oc == nil {
+			return fmt.Errorf("object class %s is not defined in the schema", class)
+		}
+		structural = structural || oc.Kind == "STRUCTURAL"
+	}
+	if !structural {
+		return fmt.Errorf("one of the object classes must be structural")
+	}
+	must, may := schema.AllowedAttributes(objectClasses)
+	if !containsFold(must, rdnAttr) && !containsFold(may, rdnAttr) {
+		return fmt.Errorf("%s is not allowed by the object classes", rdnAttr)
+	}
+	return nil
+}
+
+// newEntry checks the form and returns the DN and attributes of the entry.
+// The first value of the RDN attribute names the entry.
+func (f *attributeForm) newEntry(schema *dao.Schema, parentDN string, objectClasses []string, rdnAttr string) (string, []ldap.Attribute, error) {
+	attributes := []ldap.Attribute{{Type: "objectClass", Vals: objectClasses}}
+	rdnValue := ""
+	for _, list := range f.lists {
+		values := list.values()
+		if len(values) == 0 {
+			if f.protected[strings.ToLower(list.attr)] {
+				return "", nil, fmt.Errorf("%s is required", list.attr)
+			}
+			continue
+		}
+		if schema != nil {
+			if err := schema.ValidateValues(list.attr, values); err != nil {
+				return "", nil, err
+			}
+		}
+		if strings.EqualFold(list.attr, rdnAttr) {
+			rdnValue = values[0]
+		}
+		attributes = append(attributes, ldap.Attribute{Type: list.attr, Vals: values})
+	}
+	if rdnValue == "" {
+		return "", nil, fmt.Errorf("%s is required to name the entry", rdnAttr)
+	}
+	return dao.ChildDN(parentDN, rdnAttr, rdnValue), attributes, nil
+}
+
+// fillTemplates fills in the empty attributes that have a template with the
+// values of the other attributes
+func (f *attributeForm) fillTemplates(rules []config.CloneRule) {
+	for _, rule := range rules {
+		template, ok := rule.Template()
+		list := f.find(rule.Attr)
+		if !ok || list == nil || list.fixed != nil || len(list.entries) == 0 || len(list.values()) > 0 {
+			continue
+		}
+		value, ok := template.Expand(func(attr string) string {
+			if l := f.find(attr); l != nil {
+				if values := l.values(); len(values) > 0 {
+					return values[0]
+				}
+			}
+			return ""
+		})
+		if ok {
+			list.entries[0].SetText(value)
+		}
+	}
+}
+
+// createEntry sends the Add request to the server of a profile, empty for the
+// current one. Entries of the current profile are then shown and their parent
+// reloaded in the directory tree; the tree does not show other profiles.
+func (x *LdapAdmin) createEntry(profile, dn, parentDN string, attributes []ldap.Attribute, wizard dialog.Dialog, w fyne.Window) {
+	conn, release, err := x.connFor(profile)
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	err = dao.Add(conn, dn, attributes)
+	release()
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	wizard.Hide()
+	if profile != "" {
+		dialog.ShowInformation("Entry Created", fmt.Sprintf("%s was created in %s", dn, x.profileName(profile)), w)
+		return
+	}
+	x.reloadTreeNode(parentDN)
+	x.openEntry(dn)
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// profileResult is the outcome of a federated search on one profile
+type profileResult struct {
+	profile string
+	entries []*ldap.Entry
+	err     error
+	more    bool // 服务器还有下一页, 多服务器搜索只取第一页
+}
+
+// initProfileBar creates the profile selector shown at the top of the config panel
+func (x *LdapAdmin) initProfileBar() fyne.CanvasObject {
+	x.profileSelect = widget.NewSelect(x.profiles.Names(), x.switchProfile)
+	x.profileSelect.PlaceHolder = "选择配置"
+	if name, _ := x.ldapConn.Name.Get(); name != "" && x.profiles.Find(name) != nil {
+		x.profileSelect.Selected = name
+	}
+	return container.NewBorder(nil, nil, nil,
+		container.NewHBox(
+			widget.NewButton("保存配置", x.saveProfile),
+			widget.NewButton("删除配置", x.deleteProfile),
+		),
+		x.profileSelect,
+	)
+}
+
+// switchProfile loads a saved profile into the config panel and reconnects
+func (x *LdapAdmin) switchProfile(name string) {
+	data := x.profiles.Find(name)
+	if data == nil {
+		return
+	}
+	if current := x.ldapConn.ToData(); *current == *data {
+		return
+	}
+	x.ldapConn.GetByData(data)
+	x.ldapConn.Save()
+	x.searchReq.BaseDN.Set("") // 由新服务器的 rootDSE 填充
+	go x.connect()
+}
+
+// saveProfile stores the config panel as a named profile
+func (x *LdapAdmin) saveProfile() {
+	save := func(name string) {
+		x.ldapConn.Name.Set(name)
+		x.profiles.Put(x.ldapConn.ToData())
+		x.profiles.Save()
+		x.ldapConn.Save()
+		x.profileSelect.SetOptions(x.profiles.Names())
+		x.profileSelect.Selected = name
+		x.profileSelect.Refresh()
+		go x.connect()
+	}
+	if name, _ := x.ldapConn.Name.Get(); name != "" {
+		save(name)
+		return
+	}
+	askText("Save Profile", "Name", "", x.windows, save)
+}
+
+func (x *LdapAdmin) deleteProfile() {
+	name := x.profileSelect.Selected
+	if name == "" {
+		return
+	}
+	dialog.ShowConfirm("Delete Profile", fmt.Sprintf("Delete profile %q?", name), func(ok bool) {
+		if !ok {
+			return
+		}
+		x.profiles.Delete(name)
+		x.profiles.Save()
+		x.profileSelect.ClearSelected()
+		x.profileSelect.SetOptions(x.profiles.Names())
+	}, x.windows)
+}
+
+// profilePool is the connection pool of a saved profile and the connection
+// settings it was created with
+type profilePool struct {
+	*dao.LDAPPool
+	addr, port, username, password string
+}
+
+func (p profilePool) matches(data *config.LdapConfData) bool {
+	return p.addr == data.Addr && p.port == data.Port && p.username == data.Username && p.password == data.Password
+}
+
+// profilePool returns the connection pool of a profile for federated searches.
+// Pools are kept until the app stops; when the connection settings of a
+// profile change, its old pool is closed and a new one created.
+func (x *LdapAdmin) profilePool(data *config.LdapConfData) (*dao.LDAPPool, error) {
+	x.Lock()
+	old, ok := x.profilePools[data.Name]
+	x.Unlock()
+	if ok && old.matches(data) {
+		return old.LDAPPool, nil
+	}
+
+	pool, err := dao.NewLDAPPool(&dao.LDAPConfig{
+		Server:   data.Addr,
+		Port:     data.Port,
+		Username: data.Username,
+		Password: data.Password,
+		PoolSize: 2,
+		Timeout:  30 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	x.Lock()
+	if x.profilePools == nil {
+		x.profilePools = make(map[string]profilePool)
+	}
+	current, ok := x.profilePools[data.Name]
+	if ok && current.matches(data) {
+		// 另一个搜索已经创建了连接池
+		x.Unlock()
+		pool.Close()
+		return current.LDAPPool, nil
+	}
+	x.profilePools[data.Name] = profilePool{pool, data.Addr, data.Port, data.Username, data.Password}
+	x.Unlock()
+	if ok {
+		current.Close()
+	}
+	return pool, nil
+}
+
+func (x *LdapAdmin) closeProfilePools() {
+	x.Lock()
+	defer x.Unlock()
+	for _, pool := range x.profilePools {
+		pool.Close()
+	}
+	x.profilePools = nil
+}
+
+// FederatedSearchShow asks which profiles to run the current search against
+func (x *LdapAdmin) FederatedSearchShow() {
+	names := x.profiles.Names()
+	if len(names) == 0 {
+		dialog.ShowInformation("Search Profiles", "Save at least one profile first", x.windows)
+		return
+	}
+	check := widget.NewCheckGroup(names, nil)
+	if name, _ := x.ldapConn.Name.Get(); name != "" {
+		check.SetSelected([]string{name})
+	}
+	dialog.ShowCustomConfirm("Search Profiles", "Search", "Cancel", container.NewVScroll(check), func(ok bool) {
+		if ok && len(check.Selected) > 0 {
+			go x.openTab(newResultTab(x, x.currentSearch(), check.Selected))
+		}
+	}, x.windows)
+}
+
+// federatedSearch runs the search of a tab on several profiles at once and
+// merges the results, remembering which profile each entry came from. The
+// returned error lists the profiles the search failed on.
+func (t *resultTab) federatedSearch() error {
+	x, names := t.x, t.profiles
+	opts := x.searchOptions(t.query)
+	limit, _ := x.ldapConn.Limit.Get()
+	if limit == 0 || limit > 100 {
+		limit = 1000
+	}
+
+	results := make([]profileResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = x.searchProfile(name, opts, limit)
+		}()
+	}
+	wg.Wait()
+
+	var data []*ldap.Entry
+	sources := make(map[*ldap.Entry]string)
+	var report, failed, truncated []string
+	for _, res := range results {
+		if dao.IsTruncated(res.err) || res.more {
+			truncated = append(truncated, res.profile)
+		} else if res.err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", res.profile, res.err))
+			report = append(report, fmt.Sprintf("%s failed", res.profile))
+			continue
+		}
+		report = append(report, fmt.Sprintf("%s: %d", res.profile, len(res.entries)))
+		for _, entry := range res.entries {
+			sources[entry] = res.profile
+			data = append(data, entry)
+		}
+	}
+	dao.SortEntries(data, t.query.Sort, x.currentSchema())
+	t.sortColumn = t.query.Sort
+
+	t.pageControl = nil // 多服务器搜索不支持翻页
+	t.setResults(data, sources)
+	t.searchReport = strings.Join(report, ", ")
+	t.truncated = ""
+	if len(truncated) > 0 {
+		t.truncated = fmt.Sprintf("results from %s are truncated by a size or time limit or to the first page", strings.Join(truncated, ", "))
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("search failed on %d of %d profiles:\n%s",
+			len(failed), len(names), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// searchProfile runs one search of a federated search
+func (x *LdapAdmin) searchProfile(name string, opts *dao.SearchOptions, limit int) profileResult {
+	res := profileResult{profile: name}
+	data := x.profiles.Find(name)
+	if data == nil {
+		res.err = fmt.Errorf("profile not found")
+		return res
+	}
+	pool, err := x.profilePool(data)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	conn, err := pool.GetConnection()
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer pool.ReleaseConnection(conn)
+	profileOpts := *opts
+	profileOpts.SizeLimit, profileOpts.TimeLimit = data.SizeLimit, data.TimeLimit
+	paging := ldap.NewControlPaging(uint32(limit))
+	res.entries, res.err = dao.Search(conn, &profileOpts, paging)
+	res.more = res.err == nil && len(paging.Cookie) > 0
+	return res
+}
+
+// profileName tells which profile a name stands for in messages, an empty
+// name being the current profile
+func (x *LdapAdmin) profileName(name string) string {
+	if name == "" {
+		name, _ = x.ldapConn.Name.Get()
+		if name == "" {
+			return "the current connection"
+		}
+	}
+	return fmt.Sprintf("profile %q", name)
+}
+
+// connFor returns a connection to the server of a profile, which is where
+// entries of a federated search came from. An empty name means the current
+// profile. release gives the connection back.
+func (x *LdapAdmin) connFor(name string) (conn *ldap.Conn, release func(), err error) {
+	if name != "" {
+		if current, _ := x.ldapConn.Name.Get(); name != current {
+			data := x.profiles.Find(name)
+			if data == nil {
+				return nil, nil, fmt.Errorf("profile %q not found", name)
+			}
+			pool, err := x.profilePool(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			conn, err = pool.GetConnection()
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn, func() { pool.ReleaseConnection(conn) }, nil
+		}
+	}
+	conn, release = x.GetConn()
+	if conn == nil {
+		return nil, nil, fmt.Errorf("failed to connect to LDAP server")
+	}
+	return conn, release, nil
+}
@@ -2,336 +2,246 @@ package app
 
 import (
 	"fmt"
-	"strings"
+	"image/color"
+	"slices"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/go-ldap/ldap/v3"
+	"github.com/google/martian/log"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
 	"github.com/wangle201210/fyne-ldap-admin/app/ext"
 )
 
-// ResultShow displays search results in an improved UI
+// maxResultTabs is how many unpinned result tabs are kept, the oldest are
+// closed when a search opens another one
+const maxResultTabs = 10
+
+// ResultShow opens the result window, which has a tab for every search
 func (x *LdapAdmin) ResultShow() {
 	if x.resultWindow != nil {
-		x.updateResultContent()
 		x.resultWindow.Show()
 		return
 	}
 
 	size := fyne.Size{Width: 1000, Height: 800}
 
-	// Create a toolbar with actions
-	toolbar := widget.NewToolbar(
-		widget.NewToolbarAction(theme.DocumentCreateIcon(), func() {
-			if x.selectData != nil {
-				x.showEditDialog(x.selectData)
-			}
-		}),
-		widget.NewToolbarSeparator(),
-		widget.NewToolbarAction(theme.ViewRefreshIcon(), func() {
-			x.selectData = nil // Clear selection before refresh
-			if x.currentList != nil {
-				x.currentList.UnselectAll()
-			}
-			x.Search()
-		}),
-	)
-
-	// Create status bar
-	x.statusLabel = widget.NewLabel(fmt.Sprintf("Found %d entries", len(x.data)))
-	statusBar := container.NewHBox(x.statusLabel)
-
-	// Create main content area
-	x.resultContent = x.createResultList()
-	mainContent := container.NewBorder(toolbar, statusBar, nil, nil, x.resultContent)
+	x.resultTabs = container.NewDocTabs()
+	x.resultTabs.CloseIntercept = func(item *container.TabItem) {
+		if t := x.tabFor(item); t != nil {
+			x.closeTab(t)
+		}
+	}
 
 	// Create and show the window
 	x.resultWindow = x.App.NewWindow("LDAP Search Results")
 	x.resultWindow.Resize(size)
-	x.resultWindow.SetContent(mainContent)
+	x.resultWindow.SetContent(ext.NewToolTipContainer(x.resultTabs))
+	x.resultWindow.Canvas().AddShortcut(&fyne.ShortcutSelectAll{}, func(fyne.Shortcut) {
+		if t := x.currentTab(); t != nil {
+			t.selectAll()
+		}
+	})
+	x.addShortcuts(x.resultWindow)
 
 	// Handle window close event
 	x.resultWindow.SetOnClosed(func() {
 		x.resultWindow = nil
-		x.resultContent = nil
-		x.currentList = nil
-		x.selectData = nil
+		x.resultTabs = nil
+		x.Lock()
+		x.tabs = nil
+		x.Unlock()
 	})
 
 	x.resultWindow.Show()
 }
 
-func (x *LdapAdmin) updateResultContent() {
-	// Clear selection and list reference
-	x.selectData = nil
-	if x.currentList != nil {
-		x.currentList.UnselectAll()
-	}
-	x.currentList = nil
-
-	if x.resultContent != nil {
-		x.resultContent.Hide()
-		x.resultContent = x.createResultList()
-
-		// Get existing toolbar and status bar
-		existingContent := x.resultWindow.Content().(*fyne.Container)
-		toolbar := existingContent.Objects[0]
-		statusBar := existingContent.Objects[1]
-
-		// Create new content with existing toolbar and status bar
-		newContent := container.NewBorder(toolbar, statusBar, nil, nil, x.resultContent)
-		x.resultWindow.SetContent(newContent)
-		x.resultContent.Show()
+// addTab shows the results of a new search in a tab of their own
+func (x *LdapAdmin) addTab(t *resultTab) {
+	x.ResultShow()
+	t.item = container.NewTabItem(t.title(), t.createContent())
+	x.Lock()
+	x.tabs = append(x.tabs, t)
+	var old []*resultTab
+	unpinned := 0
+	for i := len(x.tabs) - 1; i >= 0; i-- {
+		if x.tabs[i].pinned {
+			continue
+		}
+		if unpinned++; unpinned > maxResultTabs {
+			old = append(old, x.tabs[i])
+		}
 	}
+	x.Unlock()
+	x.resultTabs.Append(t.item)
+	x.resultTabs.Select(t.item)
 
-	if x.statusLabel != nil {
-		x.statusLabel.SetText(fmt.Sprintf("Found %d entries", len(x.data)))
+	for _, t := range old {
+		x.removeTab(t)
 	}
 }
 
-// createResultList creates an enhanced list view for LDAP entries
-func (x *LdapAdmin) createResultList() fyne.CanvasObject {
-	if len(x.data) == 0 {
-		return widget.NewLabel("No results found")
+// openTab runs the search of a new tab and shows it. Without any results
+// only the error is shown and false is returned.
+func (x *LdapAdmin) openTab(t *resultTab) bool {
+	err := t.search(true)
+	if err != nil && t.data == nil {
+		log.Errorf("Search failed: %v", err)
+		dialog.ShowError(err, x.windows)
+		return false
 	}
-
-	// Create split container
-	split := container.NewHSplit(
-		x.createEntryList(),
-		x.createDetailView(),
-	)
-	split.SetOffset(0.3)
-
-	return split
+	x.addTab(t)
+	if err != nil {
+		dialog.ShowError(err, x.resultWindow)
+	}
+	return true
 }
 
-// createEntryList creates the list of LDAP entries
-func (x *LdapAdmin) createEntryList() fyne.CanvasObject {
-	list := ext.NewList(
-		func() int { return len(x.data) },
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.AccountIcon()),
-				widget.NewLabel("Template"),
-			)
-		},
-		func(id widget.ListItemID, item fyne.CanvasObject) {
-			box := item.(*fyne.Container)
-			label := box.Objects[1].(*widget.Label)
-			entry := x.data[id]
-
-			// Get CN from DN if possible
-			displayName := entry.DN
-			if cn := entry.GetAttributeValue("cn"); cn != "" {
-				displayName = cn
-			}
-			label.SetText(displayName)
-		},
-	)
-
-	list.OnSelected = func(id widget.ListItemID) {
-		x.selectData = x.data[id]
-		x.refreshDetailView()
-
-		// Refresh the entire list to update background colors
-		if x.currentList != nil {
-			x.currentList.Refresh()
-		}
+// currentTab returns the tab shown in the result window
+func (x *LdapAdmin) currentTab() *resultTab {
+	if x.resultTabs == nil {
+		return nil
 	}
+	return x.tabFor(x.resultTabs.Selected())
+}
 
-	list.OnUnselected = func(id widget.ListItemID) {
-		x.selectData = nil
-		x.refreshDetailView()
-
-		// Refresh the entire list to update background colors
-		if x.currentList != nil {
-			x.currentList.Refresh()
+func (x *LdapAdmin) tabFor(item *container.TabItem) *resultTab {
+	for _, t := range x.tabList() {
+		if t.item == item {
+			return t
 		}
 	}
-
-	// Store the list reference for later use
-	x.currentList = &list.List
-
-	// Wrap list in a card for better visual appearance
-	return widget.NewCard("Entries", "", list)
+	return nil
 }
 
-func (x *LdapAdmin) createDetailView() fyne.CanvasObject {
-	x.detailContent = widget.NewTextGrid()
-	x.refreshDetailView()
-
-	// Wrap in a scroll container and card
-	scroll := container.NewScroll(x.detailContent)
-	return widget.NewCard("Details", "", scroll)
-}
-
-func (x *LdapAdmin) refreshDetailView() {
-	if x.selectData == nil {
-		x.detailContent.SetText("Select an entry to view details")
+// closeTab closes a tab, asking first when it is pinned
+func (x *LdapAdmin) closeTab(t *resultTab) {
+	if !t.pinned {
+		x.removeTab(t)
 		return
 	}
-
-	var details strings.Builder
-	details.WriteString(fmt.Sprintf("DN: %s\n\n", x.selectData.DN))
-
-	// Group attributes by category
-	categories := map[string][]string{
-		"Name Attributes":   {"cn", "sn", "givenName"},
-		"Contact Info":      {"mail", "telephoneNumber"},
-		"Account Details":   {"uid", "uidNumber", "gidNumber"},
-		"Organization":      {"o", "ou", "title"},
-		"System Attributes": {"objectClass", "createTimestamp", "modifyTimestamp"},
-	}
-
-	// Write attributes by category
-	for category, attrs := range categories {
-		hasCategory := false
-		for _, attr := range attrs {
-			if values := x.selectData.GetAttributeValues(attr); len(values) > 0 {
-				if !hasCategory {
-					details.WriteString(fmt.Sprintf("\n=== %s ===\n", category))
-					hasCategory = true
-				}
-				if len(values) == 1 {
-					details.WriteString(fmt.Sprintf("%s: %s\n", attr, values[0]))
-				} else {
-					details.WriteString(fmt.Sprintf("%s:\n", attr))
-					for _, v := range values {
-						details.WriteString(fmt.Sprintf("  - %s\n", v))
-					}
-				}
-			}
+	dialog.ShowConfirm("Close Tab", fmt.Sprintf("Close the pinned tab %q?", t.title()), func(ok bool) {
+		if ok {
+			x.removeTab(t)
 		}
-	}
+	}, x.resultWindow)
+}
 
-	// Write remaining attributes
-	hasOthers := false
-	for _, attr := range x.selectData.Attributes {
-		if !isAttributeInCategories(attr.Name, categories) {
-			if !hasOthers {
-				details.WriteString("\n=== Other Attributes ===\n")
-				hasOthers = true
-			}
-			if len(attr.Values) == 1 {
-				details.WriteString(fmt.Sprintf("%s: %s\n", attr.Name, attr.Values[0]))
-			} else {
-				details.WriteString(fmt.Sprintf("%s:\n", attr.Name))
-				for _, v := range attr.Values {
-					details.WriteString(fmt.Sprintf("  - %s\n", v))
-				}
-			}
+// removeTab closes a tab, and the window with the last one
+func (x *LdapAdmin) removeTab(t *resultTab) {
+	x.resultTabs.Remove(t.item)
+	x.Lock()
+	for i, tab := range x.tabs {
+		if tab == t {
+			x.tabs = append(x.tabs[:i], x.tabs[i+1:]...)
+			break
 		}
 	}
+	last := len(x.tabs) == 0
+	x.Unlock()
+	if last {
+		x.resultWindow.Close()
+	}
+}
 
-	x.detailContent.SetText(details.String())
+// tabList returns the open result tabs, x.tabs is changed under x.Lock
+func (x *LdapAdmin) tabList() []*resultTab {
+	x.Lock()
+	defer x.Unlock()
+	return slices.Clone(x.tabs)
 }
 
-func isAttributeInCategories(attr string, categories map[string][]string) bool {
-	for _, attrs := range categories {
-		for _, a := range attrs {
-			if a == attr {
-				return true
-			}
-		}
+// showEntry shows a single entry in the detail view of the current tab, or in
+// a new tab when there are no results to show it next to.
+func (x *LdapAdmin) showEntry(entry *ldap.Entry) {
+	if t := x.currentTab(); t != nil && t.hasResults() {
+		t.showEntry(entry)
+		x.resultWindow.Show()
+		return
 	}
-	return false
+	x.addTab(newEntryTab(x, entry))
 }
 
-func (x *LdapAdmin) showEditDialog(entry *ldap.Entry) {
-	var modifyRequest *ldap.ModifyRequest
-	modifyRequest = ldap.NewModifyRequest(entry.DN, nil)
-
-	// Create form items for editable attributes
-	form := &widget.Form{}
-	formItems := []*widget.FormItem{}
-
-	// Group attributes by categories
-	categories := map[string][]string{
-		"Basic Information": {"cn", "sn", "givenName", "displayName", "title"},
-		"Contact":           {"mail", "telephoneNumber", "mobile"},
-		"Organization":      {"o", "ou", "department", "company"},
-		"Account":           {"uid", "uidNumber", "gidNumber", "homeDirectory", "loginShell"},
-		"Other":             {},
+// createResultList creates an enhanced list view for LDAP entries
+func (t *resultTab) createResultList() fyne.CanvasObject {
+	t.filterResults()
+	if len(t.data) == 0 {
+		return widget.NewLabel("No results found")
 	}
 
-	// Create input fields for each attribute
-	inputs := make(map[string]*widget.Entry)
-
-	// Process attributes by category
-	for _, attrs := range categories {
-		for _, attr := range attrs {
-			if value := entry.GetAttributeValue(attr); value != "" {
-				input := widget.NewEntry()
-				input.SetText(value)
-				inputs[attr] = input
-				formItems = append(formItems, widget.NewFormItem(attr, input))
-			}
-		}
+	entries := t.createEntryList
+	if t.tableMode {
+		entries = t.createEntryTable
 	}
 
-	// Add remaining attributes to "Other" category
-	for _, attr := range entry.Attributes {
-		attrName := attr.Name
-		if !isAttributeInCategories(attrName, categories) && len(attr.Values) > 0 {
-			input := widget.NewEntry()
-			input.SetText(attr.Values[0])
-			inputs[attrName] = input
-			formItems = append(formItems, widget.NewFormItem(attrName, input))
-		}
+	// Create split container
+	split := container.NewHSplit(
+		entries(),
+		t.createDetailView(),
+	)
+	split.SetOffset(0.3)
+	if t.tableMode {
+		split.SetOffset(0.6)
 	}
 
-	form.Items = formItems
-
-	// Add save button
-	saveButton := widget.NewButton("Save", func() {
-		// Collect modifications
-		for attrName, input := range inputs {
-			newValue := input.Text
-			oldValue := entry.GetAttributeValue(attrName)
-
-			if newValue != oldValue {
-				modifyRequest.Replace(attrName, []string{newValue})
-			}
-		}
-
-		// Apply modifications
-		if len(modifyRequest.Changes) > 0 {
-			ldapConn := x.GetConn()
-			if ldapConn == nil {
-				dialog.ShowError(fmt.Errorf("failed to connect to LDAP server"), x.resultWindow)
-				return
-			}
+	return split
+}
 
-			err := ldapConn.Modify(modifyRequest)
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("failed to modify entry: %v", err), x.resultWindow)
+// createEntryList creates the list of LDAP entries
+func (t *resultTab) createEntryList() fyne.CanvasObject {
+	list := ext.NewList(
+		func() int { return t.shownCount() },
+		func() fyne.CanvasObject {
+			source := widget.NewLabel("")
+			source.Importance = widget.LowImportance
+			return container.NewStack(
+				canvas.NewRectangle(color.Transparent),
+				container.NewHBox(
+					widget.NewIcon(theme.AccountIcon()),
+					widget.NewRichTextWithText("Template"),
+					widget.NewRichText(),
+					source,
+				),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*fyne.Container)
+			box := row.Objects[1].(*fyne.Container)
+			label := box.Objects[1].(*widget.RichText)
+			entry := t.shownEntry(id)
+			if entry == nil {
 				return
 			}
+			t.selectionBackground(row.Objects[0].(*canvas.Rectangle), entry)
 
-			// Show success message
-			dialog.ShowInformation("Success", "Entry modified successfully", x.resultWindow)
-
-			// Refresh the display after successful modification
-			x.Search()
-		}
-	})
+			box.Objects[0].(*widget.Icon).SetResource(t.entryIcon(entry))
+			setRichText(label, t.highlightMatches(t.entryName(entry)))
+			setBadges(box.Objects[2].(*widget.RichText), dao.EntryBadges(entry))
 
-	// Create content container with scroll
-	content := container.NewVBox(
-		form,
-		container.NewHBox(
-			widget.NewLabel(""), // spacer
-			saveButton,
-		),
+			// 多服务器搜索时显示来源
+			source := box.Objects[3].(*widget.Label)
+			if name := t.sourceOf(entry); name != "" {
+				source.SetText("[" + name + "]")
+				source.Show()
+			} else {
+				source.Hide()
+			}
+		},
 	)
 
-	scroll := container.NewScroll(content)
+	// 选中状态由 t.selection 记录并绘制, 列表本身不保留选中, 以便再次点击同一行
+	list.OnSelected = func(id widget.ListItemID) {
+		t.clickEntry(id)
+		list.Unselect(id)
+	}
+
+	// Store the list reference for later use
+	t.currentList = &list.List
 
-	// Show custom dialog with result window as parent
-	customDialog := dialog.NewCustom("Edit Entry", "Close", scroll, x.resultWindow)
-	customDialog.Resize(fyne.NewSize(800, 600))
-	customDialog.Show()
+	// Wrap list in a card for better visual appearance
+	return widget.NewCard("Entries", "", list)
 }
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
+)
+
+const (
+	filterInColumns       = "DN and columns"
+	filterInAllAttributes = "All attributes"
+)
+
+// initResultFilter creates the toolbar item that narrows the shown results
+// without asking the server again.
+func (t *resultTab) initResultFilter() widget.ToolbarItem {
+	t.filterEntry = ext.NewEntry()
+	t.filterEntry.SetPlaceHolder("Filter results")
+	t.filterEntry.SetText(t.resultFilter)
+	t.filterEntry.OnChanged = func(text string) {
+		t.resultFilter = text
+		t.applyResultFilter()
+	}
+
+	in := widget.NewSelect([]string{filterInColumns, filterInAllAttributes}, func(s string) {
+		t.resultFilterAll = s == filterInAllAttributes
+		t.applyResultFilter()
+	})
+	in.Selected = filterInColumns
+	if t.resultFilterAll {
+		in.Selected = filterInAllAttributes
+	}
+
+	size := fyne.NewSize(250, t.filterEntry.MinSize().Height)
+	return ext.NewToolbarObject(container.NewHBox(
+		widget.NewIcon(theme.SearchIcon()),
+		container.NewGridWrap(size, t.filterEntry),
+		in,
+	))
+}
+
+// filterResults fills t.shown with the results matching the result filter,
+// t.data itself is never changed by filtering.
+func (t *resultTab) filterResults() {
+	t.mu.Lock()
+	data := t.data
+	t.mu.Unlock()
+	shown := data
+	if needle := strings.ToLower(strings.TrimSpace(t.resultFilter)); needle != "" {
+		shown = make([]*ldap.Entry, 0, len(data))
+		for _, entry := range data {
+			if t.entryMatches(entry, needle) {
+				shown = append(shown, entry)
+			}
+		}
+	}
+	t.mu.Lock()
+	t.shown = shown
+	t.mu.Unlock()
+}
+
+// applyResultFilter filters the results again and refreshes the views
+func (t *resultTab) applyResultFilter() {
+	t.filterResults()
+	t.clearSelection(true)
+	if entry := t.detailEntry(); entry != nil && !t.isShown(entry) {
+		t.setDetailEntry(nil)
+	}
+	if t.detailContent != nil {
+		t.refreshDetailView()
+	}
+	t.refreshSelection()
+	if t.statusLabel != nil {
+		t.statusLabel.SetText(t.statusText())
+	}
+}
+
+// isShown reports whether an entry passes the result filter
+func (t *resultTab) isShown(entry *ldap.Entry) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range t.shown {
+		if e == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// entryMatches reports whether the DN or one of the filtered attributes
+// contains needle, which is lower case.
+func (t *resultTab) entryMatches(entry *ldap.Entry, needle string) bool {
+	if strings.Contains(strings.ToLower(entry.DN), needle) ||
+		strings.Contains(strings.ToLower(t.entryName(entry)), needle) {
+		return true
+	}
+	if t.resultFilterAll {
+		for _, attr := range entry.Attributes {
+			for _, v := range attr.Values {
+				if strings.Contains(strings.ToLower(v), needle) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	columns := t.columns
+	if columns == nil {
+		columns = defaultColumns
+	}
+	for _, col := range columns {
+		for _, v := range entry.GetAttributeValues(col.Attr) {
+			if strings.Contains(strings.ToLower(v), needle) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// highlightMatches splits text into rich text segments with every occurrence
+// of the result filter shown in bold.
+func (t *resultTab) highlightMatches(text string) []widget.RichTextSegment {
+	plain := widget.RichTextStyleInline
+	match := widget.RichTextStyleStrong
+	match.ColorName = theme.ColorNamePrimary
+
+	needle := strings.ToLower(strings.TrimSpace(t.resultFilter))
+	if needle == "" {
+		return []widget.RichTextSegment{&widget.TextSegment{Text: text, Style: plain}}
+	}
+	var segments []widget.RichTextSegment
+	lower := strings.ToLower(text)
+	for {
+		// ToLower keeps byte offsets for the text we deal with here, guard anyway
+		i := strings.Index(lower, needle)
+		if i < 0 || len(lower) != len(text) {
+			break
+		}
+		if i > 0 {
+			segments = append(segments, &widget.TextSegment{Text: text[:i], Style: plain})
+		}
+		segments = append(segments, &widget.TextSegment{Text: text[i : i+len(needle)], Style: match})
+		text, lower = text[i+len(needle):], lower[i+len(needle):]
+	}
+	if text != "" {
+		segments = append(segments, &widget.TextSegment{Text: text, Style: plain})
+	}
+	return segments
+}
+
+// setRichText replaces the content of a rich text cell
+func setRichText(rt *widget.RichText, segments []widget.RichTextSegment) {
+	rt.Segments = segments
+	rt.Refresh()
+}
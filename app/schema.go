@@ -0,0 +1,266 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// schemaItem is one row of a schema browser tab
+type schemaItem struct {
+	title  string
+	search string        // 小写的名称、OID和描述, 用于搜索
+	detail func() string // markdown
+}
+
+// SchemaShow opens the schema browser
+func (x *LdapAdmin) SchemaShow() {
+	x.Lock()
+	schema := x.schema
+	x.Unlock()
+	if schema == nil {
+		dialog.ShowInformation("Schema", "The schema has not been loaded yet, connect to the server first", x.windows)
+		return
+	}
+	if x.schemaWindow != nil {
+		x.schemaWindow.Show()
+		return
+	}
+
+	var classes, attrs, syntaxes, rules []schemaItem
+	for _, oc := range schema.ObjectClasses {
+		classes = append(classes, schemaItem{
+			title:  oc.Name(),
+			search: searchText(oc.Names, oc.OID, oc.Desc),
+			detail: func() string { return objectClassMarkdown(schema, oc) },
+		})
+	}
+	for _, at := range schema.AttributeTypes {
+		attrs = append(attrs, schemaItem{
+			title:  at.Name(),
+			search: searchText(at.Names, at.OID, at.Desc),
+			detail: func() string { return attributeTypeMarkdown(schema, at) },
+		})
+	}
+	for _, syntax := range schema.Syntaxes {
+		syntaxes = append(syntaxes, schemaItem{
+			title:  fmt.Sprintf("%s (%s)", syntax.Desc, syntax.OID),
+			search: searchText(nil, syntax.OID, syntax.Desc),
+			detail: func() string {
+				return fmt.Sprintf("## %s\n\n- **OID:** %s\n", syntax.Desc, syntax.OID)
+			},
+		})
+	}
+	for _, rule := range schema.MatchingRules {
+		rules = append(rules, schemaItem{
+			title:  rule.Name(),
+			search: searchText(rule.Names, rule.OID, rule.Desc),
+			detail: func() string { return matchingRuleMarkdown(schema, rule) },
+		})
+	}
+
+	x.schemaWindow = x.App.NewWindow("LDAP Schema")
+	x.schemaWindow.SetContent(container.NewAppTabs(
+		container.NewTabItem(fmt.Sprintf("Object Classes (%d)", len(classes)), schemaTab(classes)),
+		container.NewTabItem(fmt.Sprintf("Attribute Types (%d)", len(attrs)), schemaTab(attrs)),
+		container.NewTabItem(fmt.Sprintf("Syntaxes (%d)", len(syntaxes)), schemaTab(syntaxes)),
+		container.NewTabItem(fmt.Sprintf("Matching Rules (%d)", len(rules)), schemaTab(rules)),
+	))
+	x.schemaWindow.SetOnClosed(func() {
+		x.schemaWindow = nil
+	})
+	x.schemaWindow.Resize(fyne.NewSize(1000, 700))
+	x.schemaWindow.Show()
+}
+
+// searchText joins names, OID and description for searching
+func searchText(names []string, oid, desc string) string {
+	return strings.ToLower(strings.Join(names, " ") + " " + oid + " " + desc)
+}
+
+// schemaTab creates a searchable list of schema items with a detail pane
+func schemaTab(items []schemaItem) fyne.CanvasObject {
+	shown := items
+	detail := widget.NewRichTextFromMarkdown("")
+	detail.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject { return widget.NewLabel("Template") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(shown[id].title)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		detail.ParseMarkdown(shown[id].detail())
+	}
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search name, OID or description")
+	searchEntry.OnChanged = func(text string) {
+		text = strings.ToLower(strings.TrimSpace(text))
+		shown = items
+		if text != "" {
+			shown = nil
+			for _, item := range items {
+				if strings.Contains(item.search, text) {
+					shown = append(shown, item)
+				}
+			}
+		}
+		list.UnselectAll()
+		detail.ParseMarkdown("")
+		list.Refresh()
+	}
+
+	split := container.NewHSplit(list, container.NewScroll(detail))
+	split.SetOffset(0.35)
+	return container.NewBorder(searchEntry, nil, nil, nil, split)
+}
+
+func objectClassMarkdown(schema *dao.Schema, oc *dao.ObjectClass) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "## %s\n\n", oc.Name())
+	fmt.Fprintf(&md, "- **OID:** %s\n", oc.OID)
+	if len(oc.Names) > 1 {
+		fmt.Fprintf(&md, "- **Aliases:** %s\n", strings.Join(oc.Names[1:], ", "))
+	}
+	fmt.Fprintf(&md, "- **Kind:** %s\n", oc.Kind)
+	if oc.Desc != "" {
+		fmt.Fprintf(&md, "- **Description:** %s\n", oc.Desc)
+	}
+	if oc.Obsolete {
+		md.WriteString("- **Obsolete**\n")
+	}
+
+	chain := schema.ClassChain(oc.Name())
+	if len(chain) > 1 {
+		supers := make([]string, 0, len(chain)-1)
+		for _, sup := range chain[1:] {
+			supers = append(supers, sup.Name())
+		}
+		fmt.Fprintf(&md, "- **Superclasses:** %s\n", strings.Join(supers, " → "))
+	}
+
+	must, may := schema.AllowedAttributes([]string{oc.Name()})
+	writeAttributeList(&md, schema, "MUST", must)
+	writeAttributeList(&md, schema, "MAY", may)
+	return md.String()
+}
+
+// writeAttributeList lists attributes with their syntax and single-value flag
+func writeAttributeList(md *strings.Builder, schema *dao.Schema, title string, attrs []string) {
+	if len(attrs) == 0 {
+		return
+	}
+	fmt.Fprintf(md, "\n### %s (%d)\n\n", title, len(attrs))
+	for _, attr := range attrs {
+		var notes []string
+		if syntax := schema.Syntax(schema.AttributeSyntax(attr)); syntax != nil {
+			notes = append(notes, syntax.Desc)
+		}
+		if at := schema.AttributeType(attr); at != nil && at.SingleValue {
+			notes = append(notes, "single-value")
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(md, "- %s (%s)\n", attr, strings.Join(notes, ", "))
+		} else {
+			fmt.Fprintf(md, "- %s\n", attr)
+		}
+	}
+}
+
+func attributeTypeMarkdown(schema *dao.Schema, at *dao.AttributeType) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "## %s\n\n", at.Name())
+	fmt.Fprintf(&md, "- **OID:** %s\n", at.OID)
+	if len(at.Names) > 1 {
+		fmt.Fprintf(&md, "- **Aliases:** %s\n", strings.Join(at.Names[1:], ", "))
+	}
+	if at.Desc != "" {
+		fmt.Fprintf(&md, "- **Description:** %s\n", at.Desc)
+	}
+	if at.Sup != "" {
+		fmt.Fprintf(&md, "- **Superior:** %s\n", at.Sup)
+	}
+
+	syntaxOID := schema.AttributeSyntax(at.Name())
+	syntax := syntaxOID
+	if s := schema.Syntax(syntaxOID); s != nil && s.Desc != "" {
+		syntax = fmt.Sprintf("%s (%s)", s.Desc, syntaxOID)
+	}
+	if at.SyntaxLen != "" {
+		syntax += fmt.Sprintf(", max length %s", at.SyntaxLen)
+	}
+	if syntax != "" {
+		fmt.Fprintf(&md, "- **Syntax:** %s\n", syntax)
+	}
+	for _, rule := range [][2]string{{"Equality", at.Equality}, {"Ordering", at.Ordering}, {"Substring", at.Substr}} {
+		if rule[1] != "" {
+			fmt.Fprintf(&md, "- **%s:** %s\n", rule[0], rule[1])
+		}
+	}
+	fmt.Fprintf(&md, "- **Single-value:** %s\n", yesNo(at.SingleValue))
+	fmt.Fprintf(&md, "- **User modifiable:** %s\n", yesNo(!at.NoUserModification))
+	fmt.Fprintf(&md, "- **Usage:** %s\n", at.Usage)
+	if at.Collective {
+		md.WriteString("- **Collective**\n")
+	}
+	if at.Obsolete {
+		md.WriteString("- **Obsolete**\n")
+	}
+
+	// 哪些objectClass直接使用了这个属性
+	var mustIn, mayIn []string
+	for _, oc := range schema.ObjectClasses {
+		for _, attr := range oc.Must {
+			if schema.AttributeType(attr) == at {
+				mustIn = append(mustIn, oc.Name())
+			}
+		}
+		for _, attr := range oc.May {
+			if schema.AttributeType(attr) == at {
+				mayIn = append(mayIn, oc.Name())
+			}
+		}
+	}
+	if len(mustIn) > 0 {
+		fmt.Fprintf(&md, "\n**Required by:** %s\n", strings.Join(mustIn, ", "))
+	}
+	if len(mayIn) > 0 {
+		fmt.Fprintf(&md, "\n**Allowed by:** %s\n", strings.Join(mayIn, ", "))
+	}
+	return md.String()
+}
+
+func matchingRuleMarkdown(schema *dao.Schema, rule *dao.MatchingRule) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "## %s\n\n", rule.Name())
+	fmt.Fprintf(&md, "- **OID:** %s\n", rule.OID)
+	if rule.Desc != "" {
+		fmt.Fprintf(&md, "- **Description:** %s\n", rule.Desc)
+	}
+	syntax := rule.Syntax
+	if s := schema.Syntax(rule.Syntax); s != nil && s.Desc != "" {
+		syntax = fmt.Sprintf("%s (%s)", s.Desc, rule.Syntax)
+	}
+	if syntax != "" {
+		fmt.Fprintf(&md, "- **Syntax:** %s\n", syntax)
+	}
+	if rule.Obsolete {
+		md.WriteString("- **Obsolete**\n")
+	}
+	return md.String()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,290 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// defaultAttributes are requested when the search does not name any attributes
+var defaultAttributes = []string{
+	"cn", "sn", "givenName",
+	"mail", "telephoneNumber",
+	"uid", "uidNumber", "gidNumber",
+	"o", "ou", "title",
+	"objectClass", "createTimestamp", "modifyTimestamp",
+}
+
+// splitAttributes splits a comma separated attribute list
+func splitAttributes(s string) []string {
+	var res []string
+	for _, attr := range strings.Split(s, ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			res = append(res, attr)
+		}
+	}
+	return res
+}
+
+// profileSearches returns the history and saved searches of the current profile
+func (x *LdapAdmin) profileSearches() *config.ProfileSearches {
+	return x.searchStore.Profile(x.ldapConn.ToData().ProfileKey())
+}
+
+// currentSearch returns the search described by the main panel
+func (x *LdapAdmin) currentSearch() *config.SavedSearch {
+	res := &config.SavedSearch{}
+	res.BaseDN, _ = x.searchReq.BaseDN.Get()
+	res.Scope, _ = x.searchReq.Scope.Get()
+	res.Filter, _ = x.searchReq.Filter.Get()
+	attributes, _ := x.searchReq.Attributes.Get()
+	res.Attributes = splitAttributes(attributes)
+	res.Sort, _ = x.searchReq.Sort.Get()
+	return res
+}
+
+// applySearch fills the main panel with a saved search
+func (x *LdapAdmin) applySearch(s *config.SavedSearch) {
+	x.searchReq.BaseDN.Set(s.BaseDN)
+	scope := s.Scope
+	if scope == "" {
+		scope = dao.ScopeSub
+	}
+	x.searchReq.Scope.Set(scope)
+	x.searchReq.Filter.Set(s.Filter)
+	x.searchReq.Attributes.Set(strings.Join(s.Attributes, ", "))
+	x.searchReq.Sort.Set(s.Sort)
+}
+
+// searchOptions builds the dao search options of a search
+func (x *LdapAdmin) searchOptions(s *config.SavedSearch) *dao.SearchOptions {
+	attributes := s.Attributes
+	if len(attributes) == 0 {
+		attributes = append([]string{}, defaultAttributes...)
+		for _, attr := range append(x.templateAttributes(), dao.BadgeAttributes...) {
+			if !containsFold(attributes, attr) {
+				attributes = append(attributes, attr)
+			}
+		}
+	}
+	sizeLimit, _ := x.ldapConn.SizeLimit.Get()
+	timeLimit, _ := x.ldapConn.TimeLimit.Get()
+	return &dao.SearchOptions{
+		BaseDN:     s.BaseDN,
+		Filter:     s.Filter,
+		Scope:      dao.ParseScope(s.Scope),
+		Attributes: attributes,
+		SizeLimit:  sizeLimit,
+		TimeLimit:  timeLimit,
+	}
+}
+
+// addHistory records a search in the profile's history
+func (x *LdapAdmin) addHistory(s *config.SavedSearch) {
+	x.profileSearches().AddHistory(s)
+	x.searchStore.Save()
+	if x.historyList != nil {
+		x.historyList.Refresh()
+	}
+}
+
+// initSidebar creates the sidebar with the saved searches and the history
+func (x *LdapAdmin) initSidebar() fyne.CanvasObject {
+	x.selectedSaved = -1
+	x.savedList = widget.NewList(
+		func() int { return len(x.profileSearches().Saved) },
+		func() fyne.CanvasObject { return widget.NewLabel("Template") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(x.profileSearches().Saved[id].Name)
+		},
+	)
+	x.savedList.OnSelected = func(id widget.ListItemID) {
+		x.selectedSaved = id
+		x.applySearch(x.profileSearches().Saved[id])
+	}
+	x.savedList.OnUnselected = func(id widget.ListItemID) {
+		x.selectedSaved = -1
+	}
+
+	x.historyList = widget.NewList(
+		func() int { return len(x.profileSearches().History) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Template")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			s := x.profileSearches().History[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s @ %s", s.Filter, s.BaseDN))
+		},
+	)
+	x.historyList.OnSelected = func(id widget.ListItemID) {
+		x.applySearch(x.profileSearches().History[id])
+		x.historyList.UnselectAll()
+	}
+
+	savedToolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.DocumentSaveIcon(), x.saveCurrentSearch),
+		widget.NewToolbarAction(theme.MediaPlayIcon(), x.runSavedSearch),
+		widget.NewToolbarAction(theme.DocumentCreateIcon(), x.renameSavedSearch),
+		widget.NewToolbarAction(theme.DeleteIcon(), x.deleteSavedSearch),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.DownloadIcon(), x.importSavedSearches),
+		widget.NewToolbarAction(theme.UploadIcon(), x.exportSavedSearches),
+	)
+
+	// 切换连接配置后显示对应的搜索记录
+	profileChanged := binding.NewDataListener(x.refreshSidebar)
+	x.ldapConn.Addr.AddListener(profileChanged)
+	x.ldapConn.Port.AddListener(profileChanged)
+	x.ldapConn.Username.AddListener(profileChanged)
+
+	x.sidebarTabs = container.NewAppTabs(
+		container.NewTabItem("Tree", x.initTree()),
+		container.NewTabItem("Saved", container.NewBorder(savedToolbar, nil, nil, nil, x.savedList)),
+		container.NewTabItem("History", x.historyList),
+	)
+	return x.sidebarTabs
+}
+
+func (x *LdapAdmin) refreshSidebar() {
+	if x.savedList == nil {
+		return
+	}
+	x.savedList.UnselectAll()
+	x.selectedSaved = -1
+	x.savedList.Refresh()
+	x.historyList.Refresh()
+}
+
+// selectedSavedSearch returns the saved search selected in the sidebar
+func (x *LdapAdmin) selectedSavedSearch() *config.SavedSearch {
+	saved := x.profileSearches().Saved
+	if x.selectedSaved < 0 || x.selectedSaved >= len(saved) {
+		return nil
+	}
+	return saved[x.selectedSaved]
+}
+
+// askText shows a form with a single text entry
+func askText(title, label, initial string, parent fyne.Window, onOK func(string)) {
+	entry := widget.NewEntry()
+	entry.SetText(initial)
+	dialog.ShowForm(title, "OK", "Cancel", []*widget.FormItem{widget.NewFormItem(label, entry)}, func(ok bool) {
+		if ok && strings.TrimSpace(entry.Text) != "" {
+			onOK(strings.TrimSpace(entry.Text))
+		}
+	}, parent)
+}
+
+func (x *LdapAdmin) saveCurrentSearch() {
+	initial := ""
+	if s := x.selectedSavedSearch(); s != nil {
+		initial = s.Name
+	}
+	askText("Save Search", "Name", initial, x.windows, func(name string) {
+		s := x.currentSearch()
+		s.Name = name
+		// 当前标签的结果来自这个搜索时, 一并保存表格的列
+		if t := x.currentTab(); t != nil && t.query.Same(s) {
+			s.Columns = append([]config.ColumnLayout{}, t.columns...)
+		}
+		x.profileSearches().AddSaved(s)
+		x.searchStore.Save()
+		x.savedList.Refresh()
+	})
+}
+
+func (x *LdapAdmin) runSavedSearch() {
+	s := x.selectedSavedSearch()
+	if s == nil {
+		return
+	}
+	x.applySearch(s)
+	x.Search()
+}
+
+func (x *LdapAdmin) renameSavedSearch() {
+	s := x.selectedSavedSearch()
+	if s == nil {
+		return
+	}
+	askText("Rename Search", "Name", s.Name, x.windows, func(name string) {
+		if name == s.Name {
+			return
+		}
+		if x.profileSearches().Find(name) != nil {
+			dialog.ShowError(fmt.Errorf("a saved search named %q already exists", name), x.windows)
+			return
+		}
+		s.Name = name
+		x.searchStore.Save()
+		x.savedList.Refresh()
+	})
+}
+
+func (x *LdapAdmin) deleteSavedSearch() {
+	s := x.selectedSavedSearch()
+	if s == nil {
+		return
+	}
+	dialog.ShowConfirm("Delete Search", fmt.Sprintf("Delete saved search %q?", s.Name), func(ok bool) {
+		if !ok {
+			return
+		}
+		x.profileSearches().DeleteSaved(s.Name)
+		x.searchStore.Save()
+		x.savedList.UnselectAll()
+		x.savedList.Refresh()
+	}, x.windows)
+}
+
+func (x *LdapAdmin) importSavedSearches() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, x.windows)
+			return
+		}
+		if reader == nil {
+			return // cancelled
+		}
+		defer reader.Close()
+		n, err := x.profileSearches().ImportSaved(reader)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to import saved searches: %w", err), x.windows)
+			return
+		}
+		x.searchStore.Save()
+		x.savedList.Refresh()
+		dialog.ShowInformation("Import", fmt.Sprintf("Imported %d saved searches", n), x.windows)
+	}, x.windows)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	fileDialog.Show()
+}
+
+func (x *LdapAdmin) exportSavedSearches() {
+	fileDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, x.windows)
+			return
+		}
+		if writer == nil {
+			return // cancelled
+		}
+		defer writer.Close()
+		if err = x.profileSearches().ExportSaved(writer); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to export saved searches: %w", err), x.windows)
+		}
+	}, x.windows)
+	fileDialog.SetFileName("saved-searches.json")
+	fileDialog.Show()
+}
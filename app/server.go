@@ -0,0 +1,166 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/martian/log"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// 常见control/extension的名称, 其余只显示OID
+var knownOIDs = map[string]string{
+	"1.2.840.113556.1.4.319":     "Paged Results",
+	"1.2.840.113556.1.4.473":     "Server Side Sort",
+	"1.2.840.113556.1.4.805":     "Tree Delete",
+	"1.2.840.113556.1.4.417":     "Show Deleted",
+	"1.2.840.113556.1.4.1413":    "Permissive Modify",
+	"2.16.840.1.113730.3.4.2":    "ManageDsaIT",
+	"2.16.840.1.113730.3.4.9":    "Virtual List View",
+	"1.3.6.1.4.1.4203.1.9.1.1":   "Content Synchronization",
+	"1.3.6.1.4.1.42.2.27.8.5.1":  "Password Policy",
+	"1.3.6.1.1.12":               "Assertion",
+	"1.3.6.1.1.13.1":             "Pre-Read",
+	"1.3.6.1.1.13.2":             "Post-Read",
+	"1.3.6.1.4.1.1466.20037":     "StartTLS",
+	"1.3.6.1.4.1.4203.1.11.1":    "Password Modify",
+	"1.3.6.1.4.1.4203.1.11.3":    "Who am I?",
+	"1.3.6.1.1.8":                "Cancel",
+	"1.3.6.1.4.1.4203.1.5.1":     "All Operational Attributes",
+	"1.3.6.1.4.1.1466.101.119.1": "Dynamic Refresh",
+}
+
+// onConnected loads what the app needs to know about the server, once a
+// connection to it works.
+func (x *LdapAdmin) onConnected(conn *ldap.Conn) {
+	x.loadRootDSE(conn)
+	x.loadSchema(conn)
+}
+
+// loadRootDSE reads the rootDSE once and shows it in the server info panel
+func (x *LdapAdmin) loadRootDSE(conn *ldap.Conn) {
+	x.Lock()
+	loaded := x.rootDSE != nil
+	x.Unlock()
+	if loaded {
+		return
+	}
+
+	rootDSE, err := dao.ReadRootDSE(conn)
+	if err != nil {
+		log.Errorf("Failed to read rootDSE: %v", err)
+		return
+	}
+	x.Lock()
+	x.rootDSE = rootDSE
+	x.Unlock()
+	x.showServerInfo()
+}
+
+// loadSchema reads the server schema once, it is used to check filters and
+// by the schema browser.
+func (x *LdapAdmin) loadSchema(conn *ldap.Conn) {
+	x.Lock()
+	loaded := x.schema != nil
+	x.Unlock()
+	if loaded {
+		return
+	}
+
+	schema, err := dao.ReadSchema(conn)
+	if err != nil {
+		log.Errorf("Failed to load schema: %v", err)
+		return
+	}
+	x.Lock()
+	x.schema = schema
+	x.Unlock()
+	x.validateFilter()
+}
+
+// currentSchema returns the schema of the current profile, nil until it is read
+func (x *LdapAdmin) currentSchema() *dao.Schema {
+	x.Lock()
+	defer x.Unlock()
+	return x.schema
+}
+
+// reloadServerInfo forgets the rootDSE and schema and reads them again
+func (x *LdapAdmin) reloadServerInfo() {
+	x.Lock()
+	x.rootDSE = nil
+	x.schema = nil
+	x.Unlock()
+	go func() {
+		conn, release := x.GetConn()
+		if conn == nil {
+			return
+		}
+		defer release()
+		x.onConnected(conn)
+	}()
+}
+
+// initServerInfoPanel creates the accordion item listing the rootDSE
+func (x *LdapAdmin) initServerInfoPanel() {
+	x.serverInfo = container.NewVBox(widget.NewLabel("Not connected"))
+	x.serverInfoItem = &widget.AccordionItem{
+		Title: "服务器信息",
+		Detail: container.NewBorder(nil,
+			container.NewHBox(
+				widget.NewButton("Reload", x.reloadServerInfo),
+				widget.NewButton("Schema", x.SchemaShow),
+			),
+			nil, nil,
+			x.serverInfo,
+		),
+	}
+}
+
+// showServerInfo fills the Base DN drop-down and the server info panel from the rootDSE
+func (x *LdapAdmin) showServerInfo() {
+	x.Lock()
+	rootDSE := x.rootDSE
+	x.Unlock()
+	if rootDSE == nil || x.serverInfo == nil {
+		return
+	}
+
+	x.baseDNEntry.SetOptions(rootDSE.NamingContexts)
+	if baseDN, _ := x.searchReq.BaseDN.Get(); baseDN == "" {
+		x.searchReq.BaseDN.Set(rootDSE.DefaultBaseDN())
+	}
+
+	vendor := strings.TrimSpace(rootDSE.VendorName + " " + rootDSE.VendorVersion)
+	if vendor == "" {
+		vendor = "unknown"
+	}
+	form := widget.NewForm(
+		widget.NewFormItem("Vendor", widget.NewLabel(vendor)),
+		widget.NewFormItem("LDAP versions", widget.NewLabel(strings.Join(rootDSE.SupportedLDAPVersion, ", "))),
+		widget.NewFormItem("Naming contexts", widget.NewLabel(strings.Join(rootDSE.NamingContexts, "\n"))),
+		widget.NewFormItem("SASL mechanisms", widget.NewLabel(strings.Join(rootDSE.SupportedSASLMechanisms, ", "))),
+		widget.NewFormItem("Controls", widget.NewLabel(describeOIDs(rootDSE.SupportedControl))),
+		widget.NewFormItem("Extensions", widget.NewLabel(describeOIDs(rootDSE.SupportedExtension))),
+	)
+	x.serverInfo.Objects = []fyne.CanvasObject{form}
+	x.serverInfo.Refresh()
+	x.refreshTree()
+}
+
+// describeOIDs lists OIDs one per line, with their name when known
+func describeOIDs(oids []string) string {
+	lines := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		if name, ok := knownOIDs[oid]; ok {
+			lines = append(lines, fmt.Sprintf("%s (%s)", name, oid))
+		} else {
+			lines = append(lines, oid)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
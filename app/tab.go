@@ -0,0 +1,408 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+// tabTitleLength cuts long filters in tab titles
+const tabTitleLength = 30
+
+// resultTab holds the results of one search, with its own paging state,
+// selection and view settings, and the widgets showing them.
+type resultTab struct {
+	x        *LdapAdmin
+	item     *container.TabItem
+	name     string // 用户设置的标签名, 为空时使用 filter
+	pinned   bool   // 固定的标签不会被新的搜索关闭
+	query    *config.SavedSearch
+	profiles []string // 多服务器搜索的配置, 为空表示当前配置
+
+	// mu 保护 data, shown, selection, selectData, sources 和显示缓存. 结果在后台改变,
+	// 列表和表格绘制时读取, 持有时不能刷新界面
+	mu sync.Mutex
+
+	pageControl     *ldap.ControlPaging
+	prevPages       [][]*ldap.Entry        // 之前的页, 向前翻页时显示
+	nextPages       [][]*ldap.Entry        // 向前翻页后, 已读过的后面的页
+	data            []*ldap.Entry          // 搜索到的结果
+	shown           []*ldap.Entry          // data 中通过过滤显示的结果
+	selectData      *ldap.Entry            // 详情中显示的结果
+	selection       map[*ldap.Entry]bool   // 多选的结果
+	selectAnchor    int                    // shift 多选的起点, -1 表示没有
+	sources         map[*ldap.Entry]string // 多服务器搜索时每条结果来自的配置
+	searchReport    string                 // 多服务器搜索各服务器的结果
+	truncated       string                 // 结果因大小或时间限制不完整时的说明
+	tableMode       bool                   // 以表格显示结果
+	columns         []config.ColumnLayout
+	sortColumn      string                              // 表格的排序列, 以 - 开头表示倒序
+	resultSaved     *config.SavedSearch                 // 结果对应的收藏搜索, 用于记住表格的列
+	resultFilter    string                              // 在结果中过滤的文本
+	resultFilterAll bool                                // 过滤时匹配所有属性, 否则只匹配DN和表格的列
+	templates       map[string][]config.DisplayTemplate // 各配置的显示模板, 刷新时重新读取
+	icons           map[string][]config.ClassIcon       // 各配置的图标, 刷新时重新读取
+
+	body            *fyne.Container
+	statusLabel     *widget.Label
+	truncatedBanner *fyne.Container
+	currentList     *widget.List
+	currentTable    *widget.Table
+	detailContent   *fyne.Container
+	batchButton     *widget.Button
+	filterEntry     *ext.Entry
+	prevPageAction  *widget.ToolbarAction
+	nextPageAction  *widget.ToolbarAction
+	pinButton       *widget.Button
+}
+
+// newResultTab creates a tab for a search, on the given profiles or on the
+// current profile when there are none. It is empty until searched.
+func newResultTab(x *LdapAdmin, query *config.SavedSearch, profiles []string) *resultTab {
+	return &resultTab{x: x, query: query, profiles: profiles, selectAnchor: -1}
+}
+
+// newEntryTab creates a tab showing a single entry, re-running it reads the
+// entry again.
+func newEntryTab(x *LdapAdmin, entry *ldap.Entry) *resultTab {
+	t := newResultTab(x, &config.SavedSearch{
+		BaseDN:     entry.DN,
+		Scope:      dao.ScopeBase,
+		Filter:     "(objectClass=*)",
+		Attributes: []string{"*"},
+	}, nil)
+	t.name = entry.DN
+	if dn, err := ldap.ParseDN(entry.DN); err == nil && len(dn.RDNs) > 0 {
+		t.name = dn.RDNs[0].String()
+	}
+	t.data = []*ldap.Entry{entry}
+	t.selectData = entry
+	return t
+}
+
+// title is the text of the tab
+func (t *resultTab) title() string {
+	title := t.name
+	if title == "" {
+		title = t.query.Filter
+		if runes := []rune(title); len(runes) > tabTitleLength {
+			title = string(runes[:tabTitleLength]) + "…"
+		}
+		if len(t.profiles) > 0 {
+			title = fmt.Sprintf("%s [%d profiles]", title, len(t.profiles))
+		}
+	}
+	return title
+}
+
+// search runs the query of the tab, or reads the next page when isFirst is
+// false. The results are kept when the search fails.
+func (t *resultTab) search(isFirst bool) error {
+	if len(t.profiles) > 0 {
+		if !isFirst {
+			return fmt.Errorf("searches on several profiles have no pages")
+		}
+		return t.federatedSearch()
+	}
+
+	if !isFirst && len(t.nextPages) > 0 {
+		// 已读过的页不再查询, 分页的 cookie 只能使用一次
+		t.prevPages = append(t.prevPages, t.data)
+		last := len(t.nextPages) - 1
+		t.setResults(t.nextPages[last], nil)
+		t.nextPages = t.nextPages[:last]
+		return nil
+	}
+
+	x := t.x
+	ldapConn, release := x.GetConn()
+	if ldapConn == nil {
+		return fmt.Errorf("failed to establish LDAP connection")
+	}
+	defer release()
+
+	x.onConnected(ldapConn)
+
+	limit, _ := x.ldapConn.Limit.Get()
+	if limit == 0 || limit > 100 {
+		limit = 1000
+	}
+
+	pageControl := t.pageControl
+	if isFirst {
+		pageControl = ldap.NewControlPaging(uint32(limit))
+	}
+	if pageControl == nil {
+		return fmt.Errorf("no more pages available")
+	}
+
+	entries, err := dao.Search(ldapConn, x.searchOptions(t.query), pageControl)
+	if err != nil && !dao.IsTruncated(err) {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	dao.SortEntries(entries, t.query.Sort, x.currentSchema())
+	t.sortColumn = t.query.Sort
+	if isFirst {
+		t.useSavedColumns()
+	}
+
+	t.pageControl = pageControl
+	if len(pageControl.Cookie) == 0 {
+		t.pageControl = nil // 没有下一页
+	}
+	if isFirst {
+		t.prevPages, t.nextPages = nil, nil
+	} else {
+		t.prevPages = append(t.prevPages, t.data)
+	}
+	t.setResults(entries, nil)
+	t.searchReport = ""
+	t.truncated = ""
+	if err != nil {
+		t.truncated = err.Error()
+	}
+	return nil
+}
+
+// rerun searches again, or reads the next page, and shows the results
+func (t *resultTab) rerun(isFirst bool) {
+	err := t.search(isFirst)
+	t.refresh()
+	if err != nil {
+		dialog.ShowError(err, t.x.resultWindow)
+	}
+}
+
+// previousPage shows the page before the current one again, from memory
+func (t *resultTab) previousPage() {
+	if len(t.prevPages) == 0 {
+		return
+	}
+	t.nextPages = append(t.nextPages, t.data)
+	last := len(t.prevPages) - 1
+	t.setResults(t.prevPages[last], nil)
+	t.prevPages = t.prevPages[:last]
+	t.refresh()
+}
+
+// hasNextPage tells whether the server has more results or the next page
+// was read before
+func (t *resultTab) hasNextPage() bool {
+	return t.pageControl != nil || len(t.nextPages) > 0
+}
+
+func (t *resultTab) togglePin() {
+	t.pinned = !t.pinned
+	t.refreshTitle()
+}
+
+func (t *resultTab) rename() {
+	askText("Rename Tab", "Name", t.title(), t.x.resultWindow, func(name string) {
+		t.name = name
+		t.refreshTitle()
+	})
+}
+
+// refreshTitle shows the name and the pinned state in the tab
+func (t *resultTab) refreshTitle() {
+	if t.item == nil {
+		return
+	}
+	t.item.Text = t.title()
+	t.item.Icon = nil
+	t.pinButton.SetText("Pin")
+	if t.pinned {
+		t.item.Icon = theme.MediaRecordIcon()
+		t.pinButton.SetText("Unpin")
+	}
+	t.x.resultTabs.Refresh()
+}
+
+// createContent creates the toolbar, result views and status bar of the tab
+func (t *resultTab) createContent() fyne.CanvasObject {
+	t.prevPageAction = widget.NewToolbarAction(theme.MediaFastRewindIcon(), t.previousPage)
+	t.nextPageAction = widget.NewToolbarAction(theme.MediaFastForwardIcon(), func() { t.rerun(false) })
+	t.pinButton = widget.NewButton("Pin", t.togglePin)
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.DocumentCreateIcon(), func() {
+			if entry := t.detailEntry(); entry != nil {
+				t.showEditDialog(entry)
+			}
+		}),
+		widget.NewToolbarAction(theme.ContentCopyIcon(), t.cloneCurrent),
+		widget.NewToolbarAction(theme.ContentCutIcon(), t.moveCurrent),
+		widget.NewToolbarAction(theme.DeleteIcon(), t.deleteCurrent),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.GridIcon(), func() {
+			t.tableMode = !t.tableMode
+			t.refresh()
+		}),
+		widget.NewToolbarAction(theme.SettingsIcon(), t.showColumnsDialog),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.CheckButtonCheckedIcon(), t.selectAll),
+		ext.NewToolbarObject(t.initBatchButton()),
+		widget.NewToolbarSeparator(),
+		t.initResultFilter(),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.ViewRefreshIcon(), func() { t.rerun(true) }),
+		t.prevPageAction,
+		t.nextPageAction,
+		widget.NewToolbarSpacer(),
+		ext.NewToolbarObject(container.NewHBox(t.pinButton, widget.NewButton("Rename...", t.rename))),
+	)
+
+	// Create status bar
+	t.statusLabel = widget.NewLabel("")
+	statusBar := container.NewHBox(t.statusLabel)
+
+	// Banner shown when the server stopped the search early
+	bannerText := widget.NewLabel("")
+	bannerText.Importance = widget.WarningImportance
+	t.truncatedBanner = container.NewHBox(widget.NewIcon(theme.WarningIcon()), bannerText)
+
+	t.body = container.NewStack()
+	selectData := t.detailEntry()
+	t.refresh()
+	if selectData != nil {
+		t.showEntry(selectData)
+	}
+	return container.NewBorder(container.NewVBox(toolbar, t.truncatedBanner), statusBar, nil, nil, t.body)
+}
+
+// refresh rebuilds the result views after the results changed
+func (t *resultTab) refresh() {
+	// Clear selection and list reference
+	t.setDetailEntry(nil)
+	t.clearSelection(false)
+	t.currentList = nil
+	t.currentTable = nil
+	t.detailContent = nil
+	t.mu.Lock()
+	t.templates = nil
+	t.icons = nil
+	t.mu.Unlock()
+
+	t.body.Objects = []fyne.CanvasObject{t.createResultList()}
+	t.body.Refresh()
+	t.refreshTruncatedBanner()
+	t.refreshSelection()
+	if t.hasNextPage() {
+		t.nextPageAction.Enable()
+	} else {
+		t.nextPageAction.Disable()
+	}
+	if len(t.prevPages) > 0 {
+		t.prevPageAction.Enable()
+	} else {
+		t.prevPageAction.Disable()
+	}
+	t.statusLabel.SetText(t.statusText())
+}
+
+// showEntry shows an entry in the detail view of the tab
+func (t *resultTab) showEntry(entry *ldap.Entry) {
+	t.clearSelection(false)
+	t.refreshSelection()
+	t.setDetailEntry(entry)
+	t.refreshDetailView()
+}
+
+// hasResults tells whether the tab has results, shown or filtered out
+func (t *resultTab) hasResults() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.data) > 0
+}
+
+// detailEntry returns the entry shown in the detail view
+func (t *resultTab) detailEntry() *ldap.Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.selectData
+}
+
+func (t *resultTab) setDetailEntry(entry *ldap.Entry) {
+	t.mu.Lock()
+	t.selectData = entry
+	t.mu.Unlock()
+}
+
+// connFor returns a connection to the server an entry of the tab came from
+func (t *resultTab) connFor(entry *ldap.Entry) (conn *ldap.Conn, release func(), err error) {
+	return t.x.connFor(t.sourceOf(entry))
+}
+
+// setResults replaces the results and the profiles they came from
+func (t *resultTab) setResults(data []*ldap.Entry, sources map[*ldap.Entry]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.data = data
+	t.sources = sources
+}
+
+// sourceOf returns the profile an entry came from, empty for the current one
+func (t *resultTab) sourceOf(entry *ldap.Entry) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sources[entry]
+}
+
+// shownCount returns how many results pass the result filter
+func (t *resultTab) shownCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.shown)
+}
+
+// shownEntry returns the shown result in a row, nil when the row is gone
+func (t *resultTab) shownEntry(id int) *ldap.Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if id < 0 || id >= len(t.shown) {
+		return nil
+	}
+	return t.shown[id]
+}
+
+// refreshTruncatedBanner shows the banner when the results are incomplete
+func (t *resultTab) refreshTruncatedBanner() {
+	if t.truncated == "" {
+		t.truncatedBanner.Hide()
+		return
+	}
+	label := t.truncatedBanner.Objects[1].(*widget.Label)
+	label.SetText(fmt.Sprintf("Results truncated: %s. Narrow the filter or raise the limit to see all entries.", t.truncated))
+	t.truncatedBanner.Show()
+}
+
+// statusText describes the results for the status bar
+func (t *resultTab) statusText() string {
+	text := fmt.Sprintf("Found %d entries", len(t.data))
+	if len(t.shown) != len(t.data) {
+		text = fmt.Sprintf("Showing %d of %d entries", len(t.shown), len(t.data))
+	}
+	if t.searchReport != "" {
+		text += fmt.Sprintf(" (%s)", t.searchReport)
+	}
+	if len(t.prevPages) > 0 || t.hasNextPage() {
+		text += fmt.Sprintf(", page %d", len(t.prevPages)+1)
+	}
+	if t.hasNextPage() {
+		text += ", more pages available"
+	}
+	if strings.TrimSpace(t.query.BaseDN) != "" {
+		text += " in " + t.query.BaseDN
+	}
+	return text
+}
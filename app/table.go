@@ -0,0 +1,234 @@
+package app
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
+	"github.com/wangle201210/fyne-ldap-admin/config"
+)
+
+const defaultColumnWidth float32 = 160
+
+// sourceSortKey sorts the results of a federated search by profile, "@"
+// cannot appear in attribute names
+const sourceSortKey = "@profile"
+
+// defaultColumns are shown in the result table until the user picks others
+var defaultColumns = []config.ColumnLayout{{Attr: "cn"}, {Attr: "uid"}, {Attr: "mail"}, {Attr: "ou"}}
+
+// createEntryTable creates the table view of the results, one column per
+// chosen attribute. Clicking a header sorts, dragging its edge resizes.
+func (t *resultTab) createEntryTable() fyne.CanvasObject {
+	if t.columns == nil {
+		t.columns = append([]config.ColumnLayout{}, defaultColumns...)
+	}
+
+	table := ext.NewTable(
+		func() (int, int) { return t.shownCount(), len(t.columns) + t.sourceColumns() },
+		func() fyne.CanvasObject {
+			text := widget.NewRichTextWithText("Template")
+			text.Truncation = fyne.TextTruncateEllipsis
+			return container.NewStack(canvas.NewRectangle(color.Transparent), text)
+		},
+		func(id widget.TableCellID, item fyne.CanvasObject) {
+			cell := item.(*fyne.Container)
+			entry := t.shownEntry(id.Row)
+			if entry == nil {
+				return
+			}
+			t.selectionBackground(cell.Objects[0].(*canvas.Rectangle), entry)
+			value := t.sourceOf(entry)
+			if col := id.Col - t.sourceColumns(); col >= 0 {
+				value = t.columnValue(entry, t.columns[col].Attr)
+			}
+			setRichText(cell.Objects[1].(*widget.RichText), t.highlightMatches(value))
+		},
+	)
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("Template", nil)
+	}
+	table.UpdateHeader = func(id widget.TableCellID, item fyne.CanvasObject) {
+		button := item.(*widget.Button)
+		button.Alignment = widget.ButtonAlignLeading
+		col := id.Col - t.sourceColumns()
+		if id.Col >= 0 && col < 0 {
+			button.SetText("Profile" + t.sortMarker(sourceSortKey))
+			button.OnTapped = func() { t.sortByColumn(sourceSortKey) }
+			return
+		}
+		if col < 0 || col >= len(t.columns) {
+			return
+		}
+		attr := t.columns[col].Attr
+		button.SetText(attr + t.sortMarker(attr))
+		button.OnTapped = func() { t.sortByColumn(attr) }
+		// the header is already resized when it is updated, so this is the
+		// column width, including changes made by dragging
+		t.columnResized(col, item.Size().Width)
+	}
+	if t.sourceColumns() > 0 {
+		table.SetColumnWidth(0, defaultColumnWidth)
+	}
+	for i, col := range t.columns {
+		width := col.Width
+		if width == 0 {
+			width = defaultColumnWidth
+		}
+		table.SetColumnWidth(i+t.sourceColumns(), width)
+	}
+
+	table.OnSelected = func(id widget.TableCellID) {
+		t.clickEntry(id.Row)
+		table.Unselect(id)
+	}
+	t.currentTable = &table.Table
+	return widget.NewCard("Entries", "", table)
+}
+
+// sourceColumns is 1 when the table starts with the profile each entry of a
+// federated search came from, 0 otherwise
+func (t *resultTab) sourceColumns() int {
+	if len(t.profiles) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// columnValue renders an attribute for a table cell, several values are
+// joined and binary values show the first line of their description.
+func (t *resultTab) columnValue(entry *ldap.Entry, attr string) string {
+	if strings.EqualFold(attr, "dn") {
+		return entry.DN
+	}
+	values := entry.GetAttributeValues(attr)
+	schema := t.x.currentSchema()
+	if kind := dao.BinaryKindOf(schema, attr); kind != dao.NotBinary {
+		raw := entry.GetRawAttributeValues(attr)
+		values = make([]string, len(raw))
+		for i, b := range raw {
+			values[i], _, _ = strings.Cut(binaryText(kind, b), "\n")
+		}
+	} else if kind := dao.TimeKindOf(schema, attr); kind != dao.NotTime {
+		values = append([]string{}, values...)
+		for i, v := range values {
+			if ts, err := dao.ParseTime(kind, v); err == nil {
+				values[i] = "never"
+				if !ts.IsZero() {
+					values[i] = ts.Local().Format(time.DateTime)
+				}
+			}
+		}
+	}
+	switch len(values) {
+	case 0:
+		return ""
+	case 1:
+		return values[0]
+	default:
+		return fmt.Sprintf("[%d] %s", len(values), strings.Join(values, "; "))
+	}
+}
+
+// sortMarker shows the sort direction in the header of the sorted column
+func (t *resultTab) sortMarker(attr string) string {
+	switch t.sortColumn {
+	case attr:
+		return " ▲"
+	case "-" + attr:
+		return " ▼"
+	}
+	return ""
+}
+
+// sortByColumn sorts the results by a column, a second click reverses the order
+func (t *resultTab) sortByColumn(attr string) {
+	if t.sortColumn == attr {
+		t.sortColumn = "-" + attr
+	} else {
+		t.sortColumn = attr
+	}
+	schema := t.x.currentSchema()
+	t.mu.Lock()
+	// 排序时复制, 过滤为空时 shown 与 data 是同一个切片, 仍在绘制
+	data := append([]*ldap.Entry{}, t.data...)
+	if strings.TrimPrefix(t.sortColumn, "-") == sourceSortKey {
+		dao.SortEntriesBy(data, func(e *ldap.Entry) string { return t.sources[e] }, t.sortColumn != sourceSortKey, false)
+	} else {
+		dao.SortEntries(data, t.sortColumn, schema)
+	}
+	t.data = data
+	t.mu.Unlock()
+	t.applyResultFilter()
+}
+
+// columnResized remembers a column width and stores it in the saved search
+// the results belong to, once the user stops dragging.
+func (t *resultTab) columnResized(col int, width float32) {
+	if width <= 0 || t.columns[col].Width == width {
+		return
+	}
+	t.columns[col].Width = width
+	t.storeColumns()
+}
+
+// setColumns replaces the table columns, keeping the widths of columns that stay
+func (t *resultTab) setColumns(attrs []string) {
+	widths := make(map[string]float32)
+	for _, col := range t.columns {
+		widths[strings.ToLower(col.Attr)] = col.Width
+	}
+	columns := make([]config.ColumnLayout, 0, len(attrs))
+	for _, attr := range attrs {
+		columns = append(columns, config.ColumnLayout{Attr: attr, Width: widths[strings.ToLower(attr)]})
+	}
+	t.columns = columns
+	t.storeColumns()
+	t.refresh()
+}
+
+func (t *resultTab) showColumnsDialog() {
+	attrs := make([]string, 0, len(t.columns))
+	for _, col := range t.columns {
+		attrs = append(attrs, col.Attr)
+	}
+	askText("Columns", "Attributes", strings.Join(attrs, ", "), t.x.resultWindow, func(text string) {
+		if attrs := splitAttributes(text); len(attrs) > 0 {
+			t.setColumns(attrs)
+		}
+	})
+}
+
+// storeColumns saves the column layout in the saved search the results came
+// from. Saving is delayed so dragging a column edge writes the file once.
+func (t *resultTab) storeColumns() {
+	if t.resultSaved == nil {
+		return
+	}
+	t.resultSaved.Columns = append([]config.ColumnLayout{}, t.columns...)
+	if t.x.saveColumnsTimer != nil {
+		t.x.saveColumnsTimer.Stop()
+	}
+	t.x.saveColumnsTimer = time.AfterFunc(time.Second, t.x.searchStore.Save)
+}
+
+// useSavedColumns remembers which saved search the new results belong to and
+// restores its column layout.
+func (t *resultTab) useSavedColumns() {
+	t.resultSaved = nil
+	if s := t.x.selectedSavedSearch(); s != nil && s.Same(t.query) {
+		t.resultSaved = s
+		if len(s.Columns) > 0 {
+			t.columns = append([]config.ColumnLayout{}, s.Columns...)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+	"github.com/wangle201210/fyne-ldap-admin/app/ext"
+)
+
+// timeValue shows a time attribute in local time with its age, the raw value
+// is in the tooltip. ok is false when the value can't be decoded.
+func (t *resultTab) timeValue(kind dao.TimeKind, value string) (obj fyne.CanvasObject, ok bool) {
+	when, err := dao.ParseTime(kind, value)
+	if err != nil {
+		return nil, false
+	}
+	label := ext.NewTipLabel(formatTime(when, time.Now()), value)
+	label.Wrapping = fyne.TextWrapBreak
+	return t.valueRow(label, value), true
+}
+
+// formatTime shows a time as local time followed by how long ago it was
+func formatTime(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format(time.DateTime), relativeTime(t, now))
+}
+
+// relativeTime describes the distance between t and now, like "3 days ago"
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	format := "%s ago"
+	if d < 0 {
+		d, format = -d, "in %s"
+	}
+	var age string
+	switch days := int(d.Hours() / 24); {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		age = plural(int(d.Minutes()), "minute")
+	case days < 1:
+		age = plural(int(d.Hours()), "hour")
+	case days < 60:
+		age = plural(days, "day")
+	case days < 2*365:
+		age = plural(days/30, "month")
+	default:
+		age = plural(days/365, "year")
+	}
+	return fmt.Sprintf(format, age)
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/martian/log"
+	"github.com/wangle201210/fyne-ldap-admin/app/dao"
+)
+
+// initTree creates the directory tree. Its roots are the naming contexts of
+// the server, children are only searched for when a node is opened.
+func (x *LdapAdmin) initTree() fyne.CanvasObject {
+	x.treeChildren = make(map[string][]string)
+	x.treeLeaves = make(map[string]bool)
+	x.treeEntries = make(map[string]*ldap.Entry)
+
+	x.dirTree = widget.NewTree(
+		x.treeChildUIDs,
+		func(uid widget.TreeNodeID) bool {
+			x.Lock()
+			defer x.Unlock()
+			return !x.treeLeaves[uid]
+		},
+		func(branch bool) fyne.CanvasObject {
+			return container.NewHBox(widget.NewIcon(theme.FolderIcon()), widget.NewLabel("Template"), widget.NewRichText())
+		},
+		func(uid widget.TreeNodeID, branch bool, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			icon := box.Objects[0].(*widget.Icon)
+			badges := box.Objects[2].(*widget.RichText)
+			x.Lock()
+			entry := x.treeEntries[uid]
+			x.Unlock()
+			switch {
+			case entry != nil:
+				icon.SetResource(entryIcon(entry, x.classIcons("")))
+				// 树上已能展开, 不再标记有子节点
+				setBadges(badges, dao.EntryBadges(entry), dao.BadgeChildren)
+			case branch:
+				icon.SetResource(theme.FolderIcon())
+				setBadges(badges, nil)
+			default:
+				icon.SetResource(theme.FileIcon())
+				setBadges(badges, nil)
+			}
+			box.Objects[1].(*widget.Label).SetText(x.treeLabel(uid))
+		},
+	)
+	x.dirTree.OnBranchOpened = func(uid widget.TreeNodeID) {
+		go x.loadTreeChildren(uid)
+	}
+	x.dirTree.OnSelected = func(uid widget.TreeNodeID) {
+		if uid == "" {
+			return
+		}
+		x.treeSelected = uid
+		x.searchReq.BaseDN.Set(uid)
+		go x.openEntry(uid)
+	}
+
+	return container.NewBorder(
+		widget.NewToolbar(
+			widget.NewToolbarAction(theme.ViewRefreshIcon(), x.refreshTree),
+			widget.NewToolbarAction(theme.ContentAddIcon(), x.NewEntryShow),
+			widget.NewToolbarAction(theme.ContentCopyIcon(), x.cloneTreeSelection),
+			widget.NewToolbarAction(theme.ContentCutIcon(), x.moveTreeSelection),
+			widget.NewToolbarAction(theme.DeleteIcon(), x.deleteTreeSelection),
+		),
+		nil, nil, nil,
+		x.dirTree,
+	)
+}
+
+// treeChildUIDs returns the known children of a node, the root node "" holds
+// the naming contexts.
+func (x *LdapAdmin) treeChildUIDs(uid widget.TreeNodeID) []widget.TreeNodeID {
+	x.Lock()
+	defer x.Unlock()
+	if uid == "" {
+		if x.rootDSE == nil {
+			return nil
+		}
+		return x.rootDSE.NamingContexts
+	}
+	return x.treeChildren[uid]
+}
+
+// treeLabel shows the naming contexts in full and everything else by its RDN
+func (x *LdapAdmin) treeLabel(uid widget.TreeNodeID) string {
+	x.Lock()
+	defer x.Unlock()
+	if x.rootDSE != nil {
+		for _, nc := range x.rootDSE.NamingContexts {
+			if nc == uid {
+				return uid
+			}
+		}
+	}
+	dn, err := ldap.ParseDN(uid)
+	if err != nil || len(dn.RDNs) == 0 {
+		return uid
+	}
+	return dn.RDNs[0].String()
+}
+
+// loadTreeChildren runs a one-level search below a node. Children are only
+// loaded once, the refresh button clears them.
+func (x *LdapAdmin) loadTreeChildren(uid widget.TreeNodeID) {
+	x.Lock()
+	_, loaded := x.treeChildren[uid]
+	x.Unlock()
+	if loaded || uid == "" {
+		return
+	}
+
+	conn, release := x.GetConn()
+	if conn == nil {
+		return
+	}
+	defer release()
+	entries, err := dao.ListChildren(conn, uid)
+	if err != nil {
+		log.Errorf("Failed to load tree: %v", err)
+		return
+	}
+
+	children := make([]string, 0, len(entries))
+	x.Lock()
+	for _, entry := range entries {
+		children = append(children, entry.DN)
+		x.treeLeaves[entry.DN] = !dao.HasChildren(entry)
+		x.treeEntries[entry.DN] = entry
+	}
+	x.treeChildren[uid] = children
+	if len(children) == 0 {
+		x.treeLeaves[uid] = true
+	}
+	x.Unlock()
+	x.dirTree.Refresh()
+}
+
+// reloadTreeNode loads the children of a node again, after entries below it
+// were added or removed
+func (x *LdapAdmin) reloadTreeNode(dn string) {
+	if x.dirTree == nil {
+		return
+	}
+	x.Lock()
+	delete(x.treeChildren, dn)
+	delete(x.treeLeaves, dn)
+	x.Unlock()
+	x.loadTreeChildren(dn)
+}
+
+// refreshTree forgets all loaded children and closes the tree
+func (x *LdapAdmin) refreshTree() {
+	if x.dirTree == nil {
+		return
+	}
+	x.Lock()
+	x.treeChildren = make(map[string][]string)
+	x.treeLeaves = make(map[string]bool)
+	x.treeEntries = make(map[string]*ldap.Entry)
+	x.Unlock()
+	x.dirTree.CloseAllBranches()
+	x.dirTree.Refresh()
+}
+
+// openEntry reads an entry and shows it in the detail view
+func (x *LdapAdmin) openEntry(dn string) {
+	conn, release := x.GetConn()
+	if conn == nil {
+		return
+	}
+	defer release()
+	entry, err := dao.ReadEntry(conn, dn, nil)
+	if err != nil {
+		dialog.ShowError(err, x.windows)
+		return
+	}
+	x.showEntry(entry)
+}
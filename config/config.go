@@ -11,28 +11,57 @@ import (
 )
 
 type LdapConf struct {
+	Name     binding.String // 配置名称
 	Addr     binding.String
 	Port     binding.String
 	Username binding.String
 	Password binding.String
-	Limit    binding.Int
+	Limit    binding.Int // 每页条数
+	// 服务器返回的最大条数, 0 表示不限制
+	SizeLimit binding.Int
+	// 服务器搜索的超时秒数, 0 表示不限制
+	TimeLimit binding.Int
+	// 快速搜索匹配的属性, 逗号分隔
+	QuickSearchAttrs binding.String
+	// 结果列表的显示模板, 每行一个 objectClass: 模板
+	DisplayTemplates binding.String
+	// 结果列表和目录树的图标, 每行一个 objectClass: 图标名
+	ClassIcons binding.String
+	// 复制条目时各属性的处理规则, 每行一个 属性: 规则
+	CloneRules binding.String
 }
 
 type LdapConfData struct {
+	Name     string
 	Addr     string
 	Port     string
 	Username string
 	Password string
 	Limit    int
+
+	SizeLimit        int
+	TimeLimit        int
+	QuickSearchAttrs string
+	DisplayTemplates string
+	ClassIcons       string
+	CloneRules       string
 }
 
 func InitLdapCon() *LdapConf {
 	res := &LdapConf{
+		Name:     binding.NewString(),
 		Addr:     binding.NewString(),
 		Port:     binding.NewString(),
 		Username: binding.NewString(),
 		Password: binding.NewString(),
 		Limit:    binding.NewInt(),
+
+		SizeLimit:        binding.NewInt(),
+		TimeLimit:        binding.NewInt(),
+		QuickSearchAttrs: binding.NewString(),
+		DisplayTemplates: binding.NewString(),
+		ClassIcons:       binding.NewString(),
+		CloneRules:       binding.NewString(),
 	}
 	res.load()
 	return res
@@ -40,20 +69,51 @@ func InitLdapCon() *LdapConf {
 
 func (x *LdapConf) ToData() *LdapConfData {
 	res := &LdapConfData{}
+	res.Name, _ = x.Name.Get()
 	res.Addr, _ = x.Addr.Get()
 	res.Port, _ = x.Port.Get()
 	res.Username, _ = x.Username.Get()
 	res.Password, _ = x.Password.Get()
 	res.Limit, _ = x.Limit.Get()
+	res.SizeLimit, _ = x.SizeLimit.Get()
+	res.TimeLimit, _ = x.TimeLimit.Get()
+	res.QuickSearchAttrs, _ = x.QuickSearchAttrs.Get()
+	res.DisplayTemplates, _ = x.DisplayTemplates.Get()
+	res.ClassIcons, _ = x.ClassIcons.Get()
+	res.CloneRules, _ = x.CloneRules.Get()
 	return res
 }
 
+// ProfileKey identifies the profile the search history and saved searches belong to
+func (x *LdapConfData) ProfileKey() string {
+	return fmt.Sprintf("%s@%s:%s", x.Username, x.Addr, x.Port)
+}
+
 func (x *LdapConf) GetByData(data *LdapConfData) {
+	x.Name.Set(data.Name)
 	x.Addr.Set(data.Addr)
 	x.Port.Set(data.Port)
 	x.Username.Set(data.Username)
 	x.Password.Set(data.Password)
 	x.Limit.Set(data.Limit)
+	x.SizeLimit.Set(data.SizeLimit)
+	x.TimeLimit.Set(data.TimeLimit)
+	if data.QuickSearchAttrs == "" {
+		data.QuickSearchAttrs = DefaultQuickSearchAttrs
+	}
+	x.QuickSearchAttrs.Set(data.QuickSearchAttrs)
+	if data.DisplayTemplates == "" {
+		data.DisplayTemplates = DefaultDisplayTemplates
+	}
+	x.DisplayTemplates.Set(data.DisplayTemplates)
+	if data.ClassIcons == "" {
+		data.ClassIcons = DefaultClassIcons
+	}
+	x.ClassIcons.Set(data.ClassIcons)
+	if data.CloneRules == "" {
+		data.CloneRules = DefaultCloneRules
+	}
+	x.CloneRules.Set(data.CloneRules)
 }
 
 func (x *LdapConf) Save() {
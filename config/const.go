@@ -4,4 +4,53 @@ const (
 	AppName        = "ldap admin"
 	AppID          = "ldap-admin"
 	ldapConfigName = "ldapConf.json"
+
+	searchConfigName  = "searches.json"
+	profileConfigName = "profiles.json"
+	maxSearchHistory  = 50
+
+	DefaultQuickSearchAttrs = "cn, uid, mail, displayName"
+	DefaultDisplayTemplates = `inetOrgPerson: {displayName} <{mail}>
+posixGroup: {cn} ({gidNumber})
+organizationalUnit: {ou}
+*: {cn}`
+	// 按顺序匹配, 先写更具体的类, 如 AD 的 computer 也是 person
+	DefaultClassIcons = `alias: alias
+computer: computer
+device: computer
+ipHost: computer
+group: group
+groupOfNames: group
+groupOfUniqueNames: group
+posixGroup: group
+person: account
+posixAccount: account
+organizationalUnit: folder
+container: folder
+organization: home
+domain: home
+dcObject: home
+*: file`
+	// clear 清空, next 取目录中最大的数字加一, keep 保留, 其它为按 {属性} 填写的模板
+	DefaultCloneRules = `uid: clear
+cn: clear
+sn: clear
+givenName: clear
+initials: clear
+displayName: {givenName} {sn}
+gecos: {givenName} {sn}
+mail: clear
+userPassword: clear
+uidNumber: next
+homeDirectory: /home/{uid}
+employeeNumber: clear
+telephoneNumber: clear
+mobile: clear
+jpegPhoto: clear
+photo: clear
+thumbnailPhoto: clear
+sAMAccountName: clear
+userPrincipalName: clear
+userCertificate: clear
+sshPublicKey: clear`
 )
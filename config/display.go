@@ -0,0 +1,116 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DisplayTemplate names the entries of one object class in the result list.
+// Attribute values are put in place of {attr}, Class "*" matches any entry.
+type DisplayTemplate struct {
+	Class    string
+	Template string
+}
+
+var templateAttr = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// ParseDisplayTemplates reads templates written one per line as
+// "objectClass: template". Empty lines and lines starting with # are skipped.
+func ParseDisplayTemplates(s string) []DisplayTemplate {
+	var res []DisplayTemplate
+	parseClassLines(s, func(class, template string) {
+		res = append(res, DisplayTemplate{Class: class, Template: template})
+	})
+	return res
+}
+
+// ClassIcon names the icon of the entries of one object class, Class "*"
+// matches any entry.
+type ClassIcon struct {
+	Class string
+	Icon  string
+}
+
+// ParseClassIcons reads icons written one per line as "objectClass: icon",
+// in the same format as the display templates.
+func ParseClassIcons(s string) []ClassIcon {
+	var res []ClassIcon
+	parseClassLines(s, func(class, icon string) {
+		res = append(res, ClassIcon{Class: class, Icon: icon})
+	})
+	return res
+}
+
+// Clone rules that are not templates
+const (
+	CloneClear = "clear" // 不复制
+	CloneNext  = "next"  // 目录中最大的值加一, 如 uidNumber
+	CloneKeep  = "keep"
+)
+
+// CloneRule tells what happens to an attribute when an entry is cloned.
+// Rules other than clear, next and keep are templates like the display
+// templates, filled in from the values of the new entry.
+type CloneRule struct {
+	Attr string
+	Rule string
+}
+
+// ParseCloneRules reads rules written one per line as "attribute: rule"
+func ParseCloneRules(s string) []CloneRule {
+	var res []CloneRule
+	parseClassLines(s, func(attr, rule string) {
+		res = append(res, CloneRule{Attr: attr, Rule: rule})
+	})
+	return res
+}
+
+// Template returns the template of a rule, ok is false for the other rules
+func (x CloneRule) Template() (template DisplayTemplate, ok bool) {
+	switch strings.ToLower(x.Rule) {
+	case CloneClear, CloneNext, CloneKeep:
+		return DisplayTemplate{}, false
+	}
+	return DisplayTemplate{Class: x.Attr, Template: x.Rule}, true
+}
+
+// parseClassLines calls fn for each "objectClass: value" line of s
+func parseClassLines(s string, fn func(class, value string)) {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		class, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		fn(strings.TrimSpace(class), strings.TrimSpace(value))
+	}
+}
+
+// Attributes returns the attributes the template shows
+func (x DisplayTemplate) Attributes() []string {
+	var res []string
+	for _, m := range templateAttr.FindAllStringSubmatch(x.Template, -1) {
+		res = append(res, strings.TrimSpace(m[1]))
+	}
+	return res
+}
+
+// Expand fills in the template, value returns the first value of an
+// attribute. ok is false when none of the attributes has a value.
+func (x DisplayTemplate) Expand(value func(attr string) string) (res string, ok bool) {
+	res = templateAttr.ReplaceAllStringFunc(x.Template, func(m string) string {
+		v := value(strings.TrimSpace(m[1 : len(m)-1]))
+		if v != "" {
+			ok = true
+		}
+		return v
+	})
+	// 去掉值为空时留下的括号
+	for _, empty := range []string{"()", "<>", "[]"} {
+		res = strings.ReplaceAll(res, empty, "")
+	}
+	return strings.Join(strings.Fields(res), " "), ok
+}
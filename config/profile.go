@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ProfileStore keeps the named connection profiles
+type ProfileStore struct {
+	sync.Mutex
+	Profiles []*LdapConfData
+}
+
+func LoadProfileStore() *ProfileStore {
+	res := &ProfileStore{}
+	if err := readJSON(profileConfigName, res); err != nil {
+		fmt.Println("读取连接配置错误:", err)
+	}
+	return res
+}
+
+func (x *ProfileStore) Save() {
+	x.Lock()
+	defer x.Unlock()
+	if err := writeJSON(profileConfigName, x); err != nil {
+		fmt.Println("保存连接配置错误:", err)
+	}
+}
+
+// Names returns the sorted profile names
+func (x *ProfileStore) Names() []string {
+	x.Lock()
+	defer x.Unlock()
+	names := make([]string, 0, len(x.Profiles))
+	for _, p := range x.Profiles {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find returns a copy of the profile with the given name
+func (x *ProfileStore) Find(name string) *LdapConfData {
+	x.Lock()
+	defer x.Unlock()
+	for _, p := range x.Profiles {
+		if p.Name == name {
+			res := *p
+			return &res
+		}
+	}
+	return nil
+}
+
+// Put stores a copy of a profile, replacing one with the same name
+func (x *ProfileStore) Put(data *LdapConfData) {
+	x.Lock()
+	defer x.Unlock()
+	p := *data
+	for i, old := range x.Profiles {
+		if old.Name == p.Name {
+			x.Profiles[i] = &p
+			return
+		}
+	}
+	x.Profiles = append(x.Profiles, &p)
+}
+
+func (x *ProfileStore) Delete(name string) {
+	x.Lock()
+	defer x.Unlock()
+	for i, p := range x.Profiles {
+		if p.Name == name {
+			x.Profiles = append(x.Profiles[:i], x.Profiles[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ColumnLayout is one column of the result table
+type ColumnLayout struct {
+	Attr  string
+	Width float32 // 0 表示默认宽度
+}
+
+// SavedSearch is one search from the history or the saved searches
+type SavedSearch struct {
+	Name       string
+	BaseDN     string
+	Scope      string
+	Filter     string
+	Attributes []string
+	Sort       string
+	Columns    []ColumnLayout `json:",omitempty"` // 结果表格的列, 只用于收藏的搜索
+}
+
+// Same reports whether both searches send the same request
+func (s *SavedSearch) Same(o *SavedSearch) bool {
+	if s.BaseDN != o.BaseDN || s.Scope != o.Scope || s.Filter != o.Filter || s.Sort != o.Sort ||
+		len(s.Attributes) != len(o.Attributes) {
+		return false
+	}
+	for i := range s.Attributes {
+		if s.Attributes[i] != o.Attributes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ProfileSearches holds the searches of one connection profile
+type ProfileSearches struct {
+	History []*SavedSearch
+	Saved   []*SavedSearch
+}
+
+// SearchStore keeps the search history and saved searches of every profile,
+// keyed by LdapConfData.ProfileKey.
+type SearchStore struct {
+	sync.Mutex
+	Profiles map[string]*ProfileSearches
+}
+
+func LoadSearchStore() *SearchStore {
+	res := &SearchStore{}
+	if err := readJSON(searchConfigName, res); err != nil {
+		fmt.Println("读取搜索记录错误:", err)
+	}
+	if res.Profiles == nil {
+		res.Profiles = make(map[string]*ProfileSearches)
+	}
+	return res
+}
+
+func (x *SearchStore) Save() {
+	x.Lock()
+	defer x.Unlock()
+	if err := writeJSON(searchConfigName, x); err != nil {
+		fmt.Println("保存搜索记录错误:", err)
+	}
+}
+
+// Profile returns the searches of a profile, creating them if needed
+func (x *SearchStore) Profile(key string) *ProfileSearches {
+	x.Lock()
+	defer x.Unlock()
+	res, ok := x.Profiles[key]
+	if !ok {
+		res = &ProfileSearches{}
+		x.Profiles[key] = res
+	}
+	return res
+}
+
+// AddHistory puts a search at the top of the history, dropping an older copy
+// of the same search and anything beyond maxSearchHistory.
+func (x *ProfileSearches) AddHistory(s *SavedSearch) {
+	history := []*SavedSearch{s}
+	for _, old := range x.History {
+		if !old.Same(s) && len(history) < maxSearchHistory {
+			history = append(history, old)
+		}
+	}
+	x.History = history
+}
+
+// Find returns the saved search with the given name
+func (x *ProfileSearches) Find(name string) *SavedSearch {
+	for _, s := range x.Saved {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddSaved stores a named search, replacing one with the same name
+func (x *ProfileSearches) AddSaved(s *SavedSearch) {
+	for i, old := range x.Saved {
+		if old.Name == s.Name {
+			x.Saved[i] = s
+			return
+		}
+	}
+	x.Saved = append(x.Saved, s)
+}
+
+func (x *ProfileSearches) DeleteSaved(name string) {
+	for i, s := range x.Saved {
+		if s.Name == name {
+			x.Saved = append(x.Saved[:i], x.Saved[i+1:]...)
+			return
+		}
+	}
+}
+
+// ExportSaved writes the saved searches as JSON
+func (x *ProfileSearches) ExportSaved(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(x.Saved)
+}
+
+// ImportSaved reads saved searches written by ExportSaved and merges them by
+// name. It returns the number of imported searches.
+func (x *ProfileSearches) ImportSaved(r io.Reader) (int, error) {
+	var searches []*SavedSearch
+	if err := json.NewDecoder(r).Decode(&searches); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, s := range searches {
+		if s == nil || s.Name == "" {
+			continue
+		}
+		x.AddSaved(s)
+		n++
+	}
+	return n, nil
+}
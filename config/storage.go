@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"fyne.io/fyne/v2"
+)
+
+// storagePath returns the path of a file in the app storage
+func storagePath(name string) string {
+	return path.Join(fyne.CurrentApp().Storage().RootURI().Path(), name)
+}
+
+// readJSON loads a JSON file from the app storage into v.
+// A missing file is not an error and leaves v untouched.
+func readJSON(name string, v interface{}) error {
+	byteValue, err := os.ReadFile(storagePath(name))
+	if os.IsNotExist(err) || len(byteValue) == 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(byteValue, v)
+}
+
+// writeJSON saves v as a JSON file in the app storage
+func writeJSON(name string, v interface{}) error {
+	marshal, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(storagePath(name), marshal, 0o600)
+}